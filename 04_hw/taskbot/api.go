@@ -0,0 +1,490 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// REST API для дашбордов и скриптов. Все запросы - с заголовком
+// Authorization: Bearer $TOKEN, токен привязан к id пользователя в телеграме,
+// и API действует от имени этого пользователя с его правами на доске:
+//
+//	GET  /api/boards/$BOARD/tasks             - невыполненные задачи доски
+//	POST /api/boards/$BOARD/tasks             - новая задача, тело - apiNewTask
+//	GET  /api/boards/$BOARD/tasks/$ID         - задача целиком
+//	POST /api/boards/$BOARD/tasks/$ID/assign  - назначить, тело - apiAssign
+//	POST /api/boards/$BOARD/tasks/$ID/resolve - выполнить, тело - apiResolve
+//	GET  /api/hooks                           - журнал доставки исходящих вебхуков, только для -tg.admin
+//	GET  /api/audit                           - журнал изменений задач в JSON lines с досок пользователя,
+//	                                            ?board=$BOARD - одной доски, ?task=$ID - одной задачи
+//	GET  /api/schema                          - JSON Schema тел запросов и ответов
+//
+// Личная доска - 0, доска группы - id чата группы. Доски, с которыми пользователь
+// не работал, для него не существуют, а на личной доске он видит только задачи,
+// которые создал или которые назначены на него.
+const apiPrefix = "/api/"
+
+var errBadPriority = errors.New("bad priority")
+
+type apiUser struct {
+	ID       int64  `json:"id"`
+	UserName string `json:"username"`
+}
+
+type apiResolution struct {
+	By   apiUser   `json:"by"`
+	At   time.Time `json:"at"`
+	Note string    `json:"note,omitempty"`
+}
+
+type apiTask struct {
+	ID          int64          `json:"id"`
+	BoardID     int64          `json:"board_id"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Due         *time.Time     `json:"due,omitempty"`
+	Priority    string         `json:"priority,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	Owner       apiUser        `json:"owner"`
+	Assignees   []apiUser      `json:"assignees"`
+	Watchers    []apiUser      `json:"watchers,omitempty"`
+	Resolution  *apiResolution `json:"resolution,omitempty"`
+}
+
+// apiNewTask - тело POST /tasks, due - как в /new: 2026-11-01 или +3d
+type apiNewTask struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Due         string   `json:"due"`
+	Priority    string   `json:"priority"`
+	Tags        []string `json:"tags"`
+}
+
+// apiAssign - тело POST /assign, без assignee задача назначается на владельца токена
+type apiAssign struct {
+	Assignee string `json:"assignee"`
+}
+
+type apiResolve struct {
+	Note string `json:"note"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// parseAPITokens разбирает -tg.api.tokens: token1:userID1,token2:userID2.
+// Токен привязан к id, а не к логину: логин можно сменить или занять чужой
+func parseAPITokens(value string) (map[string]int64, error) {
+	tokens := make(map[string]int64)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		token, id, ok := strings.Cut(item, ":")
+		userID, err := strconv.ParseInt(id, 10, 64)
+		if !ok || token == "" || err != nil {
+			return nil, fmt.Errorf("bad api token %q, want token:userid", item)
+		}
+		tokens[token] = userID
+	}
+	return tokens, nil
+}
+
+// apiHandler отвечает на запросы API и рассылает в телеграм
+// те же уведомления, что и команды в чате
+type apiHandler struct {
+	p      *telegramPresenter
+	bot    Sender
+	tokens map[string]int64
+	// hooks может быть nil, если исходящих вебхуков нет
+	hooks *hookDispatcher
+}
+
+func newAPIHandler(p *telegramPresenter, bot Sender, tokens map[string]int64, hooks *hookDispatcher) *apiHandler {
+	return &apiHandler{
+		p:      p,
+		bot:    bot,
+		tokens: tokens,
+		hooks:  hooks,
+	}
+}
+
+func (a *apiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	actor, err := a.authenticate(r)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, apiError{Error: err.Error()})
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, apiPrefix), "/")
+	if path == "schema" && r.Method == http.MethodGet {
+		writeJSON(w, http.StatusOK, apiSchema)
+		return
+	}
+	if path == "hooks" && r.Method == http.MethodGet {
+		a.hookDeliveries(w, actor)
+		return
+	}
+	if path == "audit" && r.Method == http.MethodGet {
+		a.exportAudit(w, r, actor)
+		return
+	}
+
+	// boards/$BOARD/tasks[/$ID[/action]]
+	parts := strings.Split(path, "/")
+	if len(parts) < 3 || len(parts) > 5 || parts[0] != "boards" || parts[2] != "tasks" {
+		writeJSON(w, http.StatusNotFound, apiError{Error: "not found"})
+		return
+	}
+	boardID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, apiError{Error: "bad board id"})
+		return
+	}
+	if !a.p.canRead(boardID, actor.ID) {
+		writeJSON(w, http.StatusNotFound, apiError{Error: "board not found"})
+		return
+	}
+	var taskID int64
+	if len(parts) > 3 {
+		if taskID, err = strconv.ParseInt(parts[3], 10, 64); err != nil {
+			writeJSON(w, http.StatusNotFound, apiError{Error: "bad task id"})
+			return
+		}
+	}
+
+	switch {
+	case len(parts) == 3 && r.Method == http.MethodGet:
+		a.listTasks(w, boardID, actor)
+	case len(parts) == 3 && r.Method == http.MethodPost:
+		a.createTask(w, r, boardID, actor)
+	case len(parts) == 4 && r.Method == http.MethodGet:
+		a.getTask(w, boardID, taskID, actor)
+	case len(parts) == 5 && parts[4] == "assign" && r.Method == http.MethodPost:
+		a.assignTask(w, r, boardID, taskID, actor)
+	case len(parts) == 5 && parts[4] == "resolve" && r.Method == http.MethodPost:
+		a.resolveTask(w, r, boardID, taskID, actor)
+	default:
+		writeJSON(w, http.StatusNotFound, apiError{Error: "not found"})
+	}
+}
+
+// authenticate находит пользователя по токену. Он должен хоть раз написать боту,
+// иначе неизвестен его id в телеграме
+func (a *apiHandler) authenticate(r *http.Request) (*User, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil, errors.New("missing bearer token")
+	}
+	// сравниваем со всеми токенами за одинаковое время, чтобы по задержке ответа
+	// нельзя было подбирать токен
+	var userID int64
+	found := false
+	for known, id := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(known)) == 1 {
+			userID, found = id, true
+		}
+	}
+	if !found {
+		return nil, errors.New("bad token")
+	}
+	user, ok := a.p.User(userID)
+	if !ok {
+		return nil, fmt.Errorf("user %d has never talked to the bot", userID)
+	}
+	return &user, nil
+}
+
+func (a *apiHandler) listTasks(w http.ResponseWriter, boardID int64, actor *User) {
+	tasks := a.p.OpenTasks(boardID)
+	result := make([]apiTask, 0, len(tasks))
+	for _, task := range tasks {
+		if visible(task, actor) {
+			result = append(result, newAPITask(task))
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// hookDeliveries отдает журнал доставки вебхуков. В нем события всех досок и адреса
+// подписок, в которых бывают секреты, поэтому он доступен только администратору бота
+func (a *apiHandler) hookDeliveries(w http.ResponseWriter, actor *User) {
+	if !a.p.isServerAdmin(actor.ID) {
+		writeAPIError(w, ErrForbidden)
+		return
+	}
+	deliveries := []hookAttempt{}
+	if a.hooks != nil {
+		deliveries = append(deliveries, a.hooks.deliveries()...)
+	}
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// exportAudit выгружает журнал изменений, по записи на строку. В выгрузку попадают
+// только доски, с которыми работает пользователь, и на личной доске - только его задачи
+func (a *apiHandler) exportAudit(w http.ResponseWriter, r *http.Request, actor *User) {
+	query := r.URL.Query()
+	var taskID int64
+	if value := query.Get("task"); value != "" {
+		var err error
+		if taskID, err = strconv.ParseInt(value, 10, 64); err != nil {
+			writeJSON(w, http.StatusBadRequest, apiError{Error: "bad task id"})
+			return
+		}
+	}
+	boardID, oneBoard := int64(0), query.Has("board")
+	if oneBoard {
+		var err error
+		if boardID, err = strconv.ParseInt(query.Get("board"), 10, 64); err != nil || !a.p.canRead(boardID, actor.ID) {
+			writeJSON(w, http.StatusNotFound, apiError{Error: "board not found"})
+			return
+		}
+	}
+
+	readable := make(map[int64]bool)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	encoder := json.NewEncoder(w)
+	for _, entry := range a.p.AuditLog() {
+		if (taskID != 0 && entry.TaskID != taskID) || (oneBoard && entry.BoardID != boardID) {
+			continue
+		}
+		canRead, ok := readable[entry.BoardID]
+		if !ok {
+			canRead = a.p.canRead(entry.BoardID, actor.ID)
+			readable[entry.BoardID] = canRead
+		}
+		if !canRead || !a.auditVisible(entry, actor) {
+			continue
+		}
+		if err := encoder.Encode(entry); err != nil {
+			log.Printf("Ошибка выгрузки журнала изменений: %v", err)
+			return
+		}
+	}
+}
+
+// auditVisible - видна ли пользователю запись журнала: как и задачи, на личной доске
+// видны только свои. По удаленной задаче видны только его собственные действия
+func (a *apiHandler) auditVisible(entry AuditEntry, actor *User) bool {
+	if entry.BoardID != personalBoardID {
+		return true
+	}
+	task, err := a.p.Task(entry.BoardID, entry.TaskID)
+	if err != nil {
+		return entry.Actor != nil && entry.Actor.ID == actor.ID
+	}
+	return visible(task, actor)
+}
+
+// visibleTask - задача, если пользователь ее видит, иначе ErrTaskNotFound,
+// чтобы по ответу нельзя было узнать о чужих задачах на личной доске
+func (a *apiHandler) visibleTask(boardID, taskID int64, actor *User) (Task, error) {
+	task, err := a.p.Task(boardID, taskID)
+	if err != nil {
+		return Task{}, err
+	}
+	if !visible(task, actor) {
+		return Task{}, ErrTaskNotFound
+	}
+	return task, nil
+}
+
+func (a *apiHandler) getTask(w http.ResponseWriter, boardID, taskID int64, actor *User) {
+	task, err := a.visibleTask(boardID, taskID, actor)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newAPITask(task))
+}
+
+func (a *apiHandler) createTask(w http.ResponseWriter, r *http.Request, boardID int64, actor *User) {
+	var req apiNewTask
+	if !a.decode(w, r, boardID, actor, &req) {
+		return
+	}
+
+	fields, err := req.fields(a.p.clock.Now())
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	created, err := a.p.CreateTask(boardID, actor, fields)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, newAPITask(created.Task))
+}
+
+func (a *apiHandler) assignTask(w http.ResponseWriter, r *http.Request, boardID, taskID int64, actor *User) {
+	var req apiAssign
+	if !a.decode(w, r, boardID, actor, &req) {
+		return
+	}
+	if _, err := a.visibleTask(boardID, taskID, actor); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	assigned, err := a.p.AssignTask(boardID, taskID, actor, req.Assignee)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	a.notify(assigned)
+	writeJSON(w, http.StatusOK, newAPITask(assigned.Task))
+}
+
+func (a *apiHandler) resolveTask(w http.ResponseWriter, r *http.Request, boardID, taskID int64, actor *User) {
+	var req apiResolve
+	if !a.decode(w, r, boardID, actor, &req) {
+		return
+	}
+	if _, err := a.visibleTask(boardID, taskID, actor); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	resolved, err := a.p.ResolveTask(boardID, taskID, actor, req.Note)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	a.notify(resolved)
+	writeJSON(w, http.StatusOK, newAPITask(resolved.Task))
+}
+
+// decode проверяет, что пользователь может менять задачи доски, и читает тело запроса
+func (a *apiHandler) decode(w http.ResponseWriter, r *http.Request, boardID int64, actor *User, req any) bool {
+	if !a.p.canWrite(boardID, actor.ID) {
+		writeAPIError(w, ErrForbidden)
+		return false
+	}
+	// пустое тело - это пустой объект, например для assign на себя
+	if r.ContentLength == 0 {
+		return true
+	}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: fmt.Sprintf("bad request body: %v", err)})
+		return false
+	}
+	return true
+}
+
+// visible - видна ли задача пользователю API: на личной доске общие задачи всех,
+// кто пишет боту, поэтому там видны только свои и назначенные на него
+func visible(task Task, actor *User) bool {
+	return task.BoardID != personalBoardID || task.Owner.ID == actor.ID || task.isAssignee(actor.ID)
+}
+
+func (a *apiHandler) notify(event Event) {
+	for _, n := range a.p.notifications(event) {
+		notify(a.bot, n.ChatID, n.Text)
+	}
+}
+
+func (req apiNewTask) fields(now time.Time) (newTaskFields, error) {
+	fields := newTaskFields{
+		Title:       strings.TrimSpace(req.Title),
+		Description: strings.TrimSpace(req.Description),
+	}
+	if fields.Title == "" {
+		return fields, errEmptyTitle
+	}
+	if req.Due != "" {
+		due, err := parseDue(req.Due, now)
+		if err != nil {
+			return fields, err
+		}
+		fields.Due = &due
+	}
+	if req.Priority != "" {
+		priority, ok := priorityNames[strings.ToLower(req.Priority)]
+		if !ok {
+			return fields, fmt.Errorf("%w: %q", errBadPriority, req.Priority)
+		}
+		fields.Priority = priority
+	}
+	for _, word := range req.Tags {
+		tag, ok := parseTag(word)
+		if !ok {
+			return fields, fmt.Errorf("%w: %q", ErrBadTag, word)
+		}
+		fields.Tags = addTags(fields.Tags, tag)
+	}
+	return fields, nil
+}
+
+func newAPITask(task Task) apiTask {
+	result := apiTask{
+		ID:          task.ID,
+		BoardID:     task.BoardID,
+		Title:       task.Title,
+		Description: task.Description,
+		Due:         task.Due,
+		Priority:    task.Priority.name(),
+		Tags:        task.Tags,
+		Owner:       newAPIUser(task.Owner),
+		Assignees:   newAPIUsers(task.Assignees),
+		Watchers:    newAPIUsers(task.Watchers),
+	}
+	if resolution := task.resolution(); resolution != nil {
+		result.Resolution = &apiResolution{
+			By:   newAPIUser(resolution.By),
+			At:   resolution.At,
+			Note: resolution.Note,
+		}
+	}
+	return result
+}
+
+func newAPIUser(user *User) apiUser {
+	return apiUser{ID: user.ID, UserName: user.UserName}
+}
+
+func newAPIUsers(users []*User) []apiUser {
+	result := make([]apiUser, 0, len(users))
+	for _, user := range users {
+		result = append(result, newAPIUser(user))
+	}
+	return result
+}
+
+// writeAPIError выбирает HTTP-статус по ошибке доменного API
+func writeAPIError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var (
+		unknownUser *UnknownUserError
+		notMember   *NotMemberError
+	)
+	switch {
+	case errors.Is(err, ErrTaskNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrForbidden), errors.Is(err, ErrNotAssignee):
+		status = http.StatusForbidden
+	case errors.Is(err, errEmptyTitle), errors.Is(err, errBadDue), errors.Is(err, errBadPriority), errors.Is(err, ErrBadTag):
+		status = http.StatusBadRequest
+	case errors.As(err, &unknownUser), errors.As(err, &notMember):
+		status = http.StatusUnprocessableEntity
+	}
+	writeJSON(w, status, apiError{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Printf("Ошибка записи ответа API: %v", err)
+	}
+}
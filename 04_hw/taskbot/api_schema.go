@@ -0,0 +1,79 @@
+package main
+
+import "encoding/json"
+
+// apiSchema - JSON Schema тел запросов и ответов REST API, отдается на GET /api/schema.
+// При изменении apiTask и остальных типов API схему нужно поправить вместе с ними
+var apiSchema = json.RawMessage(`{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$defs": {
+    "user": {
+      "type": "object",
+      "required": ["id", "username"],
+      "properties": {
+        "id": {"type": "integer"},
+        "username": {"type": "string"}
+      }
+    },
+    "resolution": {
+      "type": "object",
+      "required": ["by", "at"],
+      "properties": {
+        "by": {"$ref": "#/$defs/user"},
+        "at": {"type": "string", "format": "date-time"},
+        "note": {"type": "string"}
+      }
+    },
+    "task": {
+      "type": "object",
+      "required": ["id", "board_id", "title", "owner", "assignees"],
+      "properties": {
+        "id": {"type": "integer"},
+        "board_id": {"type": "integer"},
+        "title": {"type": "string"},
+        "description": {"type": "string"},
+        "due": {"type": "string", "format": "date-time"},
+        "priority": {"enum": ["low", "normal", "high"]},
+        "tags": {"type": "array", "items": {"type": "string"}},
+        "owner": {"$ref": "#/$defs/user"},
+        "assignees": {"type": "array", "items": {"$ref": "#/$defs/user"}},
+        "watchers": {"type": "array", "items": {"$ref": "#/$defs/user"}},
+        "resolution": {"$ref": "#/$defs/resolution"}
+      }
+    },
+    "task_list": {
+      "type": "array",
+      "items": {"$ref": "#/$defs/task"}
+    },
+    "new_task": {
+      "type": "object",
+      "required": ["title"],
+      "properties": {
+        "title": {"type": "string", "minLength": 1},
+        "description": {"type": "string"},
+        "due": {"type": "string", "description": "2026-11-01 или +3d"},
+        "priority": {"enum": ["low", "normal", "high"]},
+        "tags": {"type": "array", "items": {"type": "string"}}
+      }
+    },
+    "assign": {
+      "type": "object",
+      "properties": {
+        "assignee": {"type": "string", "description": "логин, без него - на себя"}
+      }
+    },
+    "resolve": {
+      "type": "object",
+      "properties": {
+        "note": {"type": "string"}
+      }
+    },
+    "error": {
+      "type": "object",
+      "required": ["error"],
+      "properties": {
+        "error": {"type": "string"}
+      }
+    }
+  }
+}`)
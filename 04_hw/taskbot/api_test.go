@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAPITokens(t *testing.T) {
+	tokens, err := parseAPITokens("t1:256, t2:512")
+	if err != nil {
+		t.Fatalf("parseAPITokens error: %s", err)
+	}
+	if tokens["t1"] != Ivanov || tokens["t2"] != Petrov || len(tokens) != 2 {
+		t.Fatalf("bad tokens: %v", tokens)
+	}
+	if _, err := parseAPITokens("t1"); err == nil {
+		t.Fatalf("token without user id is accepted")
+	}
+	if _, err := parseAPITokens("t1:ivanov"); err == nil {
+		t.Fatalf("token with username instead of id is accepted")
+	}
+}
+
+func TestAPI(t *testing.T) {
+	tds, bot := newTestBot(t)
+
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.touchUser(Ivanov, "ivanov", "")
+	manager.touchUser(Petrov, "ppetrov", "")
+	manager.touchUser(Alexandrov, "aalexandrov", "")
+	manager.touchBoard(personalBoardID, personalBoardTitle, Ivanov)
+	manager.touchBoard(personalBoardID, personalBoardTitle, Petrov)
+
+	api := newAPIHandler(manager, bot, map[string]int64{
+		"ivanov-token":     Ivanov,
+		"petrov-token":     Petrov,
+		"alexandrov-token": Alexandrov,
+	}, nil)
+	ts := httptest.NewServer(newHTTPServer("", manager.TaskManager, &botStatus{}, api, nil).Handler)
+	defer ts.Close()
+
+	call := func(method, path, token, body string, wantStatus int, result any) {
+		t.Helper()
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("NewRequest error: %s", err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s error: %s", method, path, err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != wantStatus {
+			t.Fatalf("%s %s: want status %d, have %d", method, path, wantStatus, resp.StatusCode)
+		}
+		if result != nil {
+			if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+				t.Fatalf("decode %s %s error: %s", method, path, err)
+			}
+		}
+	}
+
+	call("GET", "/api/boards/0/tasks", "", "", http.StatusUnauthorized, nil)
+	call("GET", "/api/boards/0/tasks", "wrong", "", http.StatusUnauthorized, nil)
+	call("GET", "/api/boards/0/tasks", "ivanov-toke", "", http.StatusUnauthorized, nil)
+
+	var task apiTask
+	call("POST", "/api/boards/0/tasks", "ivanov-token",
+		`{"title": "написать бота", "priority": "high", "tags": ["#api"]}`, http.StatusCreated, &task)
+	if task.ID != 1 || task.Owner.UserName != "ivanov" || task.Priority != "high" || len(task.Tags) != 1 {
+		t.Fatalf("bad created task: %+v", task)
+	}
+	call("POST", "/api/boards/0/tasks", "ivanov-token", `{"title": "x", "priority": "asap"}`, http.StatusBadRequest, nil)
+	call("POST", "/api/boards/0/tasks", "ivanov-token", `{"title": " "}`, http.StatusBadRequest, nil)
+
+	// уведомления те же, что и при /assign_1 @ppetrov в чате
+	call("POST", "/api/boards/0/tasks/1/assign", "ivanov-token", `{"assignee": "@ppetrov"}`, http.StatusOK, &task)
+	waitAnswers(t, tds, map[int64]string{
+		Petrov: ru(msgAssignedToYouBy, "написать бота", "ivanov"),
+	})
+	call("POST", "/api/boards/0/tasks/1/assign", "ivanov-token", `{"assignee": "@nobody"}`, http.StatusUnprocessableEntity, nil)
+
+	call("GET", "/api/boards/0/tasks/1", "petrov-token", "", http.StatusOK, &task)
+	if len(task.Assignees) != 1 || task.Assignees[0].ID != Petrov {
+		t.Fatalf("bad assignees: %+v", task.Assignees)
+	}
+
+	call("POST", "/api/boards/0/tasks/1/resolve", "petrov-token", `{"note": "готово"}`, http.StatusOK, &task)
+	if task.Resolution == nil || task.Resolution.By.UserName != "ppetrov" || task.Resolution.Note != "готово" {
+		t.Fatalf("bad resolution: %+v", task.Resolution)
+	}
+	waitAnswers(t, tds, map[int64]string{
+		Ivanov: ru(msgResolvedBy, "написать бота", "ppetrov") + ru(msgResolutionNote, "готово"),
+	})
+
+	var tasks []apiTask
+	call("GET", "/api/boards/0/tasks", "ivanov-token", "", http.StatusOK, &tasks)
+	if len(tasks) != 0 {
+		t.Fatalf("resolved task is listed: %+v", tasks)
+	}
+	call("GET", "/api/boards/0/tasks/1", "ivanov-token", "", http.StatusOK, &task)
+	call("POST", "/api/boards/0/tasks/1/resolve", "ivanov-token", "", http.StatusNotFound, nil)
+	call("GET", "/api/boards/0/tasks/42", "ivanov-token", "", http.StatusNotFound, nil)
+	call("DELETE", "/api/boards/0/tasks/1", "ivanov-token", "", http.StatusNotFound, nil)
+}
+
+func TestAPIBoardAccess(t *testing.T) {
+	_, bot := newTestBot(t)
+
+	const group int64 = -100
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.touchUser(Ivanov, "ivanov", "")
+	manager.touchUser(Petrov, "ppetrov", "")
+	manager.touchUser(Alexandrov, "aalexandrov", "")
+	manager.touchBoard(personalBoardID, personalBoardTitle, Ivanov)
+	manager.touchBoard(personalBoardID, personalBoardTitle, Petrov)
+	manager.touchBoard(group, "backend", Ivanov)
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new личная задача")
+	manager.addTasks(group, Ivanov, "ivanov", "/new задача группы")
+
+	api := newAPIHandler(manager, bot, map[string]int64{
+		"ivanov-token":     Ivanov,
+		"petrov-token":     Petrov,
+		"alexandrov-token": Alexandrov,
+	}, nil)
+	ts := httptest.NewServer(newHTTPServer("", manager.TaskManager, &botStatus{}, api, nil).Handler)
+	defer ts.Close()
+
+	status := func(method, path, token, body string) int {
+		t.Helper()
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("NewRequest error: %s", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s error: %s", method, path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	for _, tc := range []struct {
+		method, path, token, body string
+		want                      int
+	}{
+		// Петров не работает с группой, для него ее доски нет
+		{"GET", "/api/boards/-100/tasks", "petrov-token", "", http.StatusNotFound},
+		{"GET", "/api/boards/-100/tasks/2", "petrov-token", "", http.StatusNotFound},
+		{"POST", "/api/boards/-100/tasks", "petrov-token", `{"title": "чужая"}`, http.StatusNotFound},
+		{"POST", "/api/boards/-100/tasks/2/assign", "petrov-token", "", http.StatusNotFound},
+		{"POST", "/api/boards/-100/tasks/2/resolve", "petrov-token", "", http.StatusNotFound},
+		// Александров никогда не писал боту в личку
+		{"GET", "/api/boards/0/tasks", "alexandrov-token", "", http.StatusNotFound},
+		// на личной доске чужие задачи не видны
+		{"GET", "/api/boards/0/tasks/1", "petrov-token", "", http.StatusNotFound},
+		{"POST", "/api/boards/0/tasks/1/assign", "petrov-token", "", http.StatusNotFound},
+		{"POST", "/api/boards/0/tasks/1/assign", "petrov-token", `{"assignee": "@ivanov"}`, http.StatusNotFound},
+		{"POST", "/api/boards/0/tasks/1/resolve", "petrov-token", "", http.StatusNotFound},
+		{"GET", "/api/boards/0/tasks/1", "ivanov-token", "", http.StatusOK},
+		{"GET", "/api/boards/-100/tasks/2", "ivanov-token", "", http.StatusOK},
+	} {
+		if have := status(tc.method, tc.path, tc.token, tc.body); have != tc.want {
+			t.Errorf("%s %s by %s: want status %d, have %d", tc.method, tc.path, tc.token, tc.want, have)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", ts.URL+"/api/boards/0/tasks", nil)
+	req.Header.Set("Authorization", "Bearer petrov-token")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /api/boards/0/tasks error: %s", err)
+	}
+	defer resp.Body.Close()
+	var tasks []apiTask
+	if err := json.NewDecoder(resp.Body).Decode(&tasks); err != nil {
+		t.Fatalf("decode error: %s", err)
+	}
+	if len(tasks) != 0 {
+		t.Fatalf("other users' personal tasks are listed: %+v", tasks)
+	}
+
+	// попытки назначить и выполнить чужую задачу ее не изменили
+	task, err := manager.Task(personalBoardID, 1)
+	if err != nil {
+		t.Fatalf("Task error: %s", err)
+	}
+	if len(task.Assignees) != 0 || task.isResolved() {
+		t.Fatalf("hidden task is changed: %+v", task)
+	}
+}
+
+// TestAPIUserRenamed - токен привязан к id: тот, кто занял старый логин, не получает доступ
+func TestAPIUserRenamed(t *testing.T) {
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.touchUser(Ivanov, "ivanov", "")
+	manager.touchBoard(personalBoardID, personalBoardTitle, Ivanov)
+	manager.touchUser(Ivanov, "ivanov_new", "")
+	manager.touchUser(Petrov, "IVANOV", "")
+
+	api := newAPIHandler(manager, nil, map[string]int64{"token": Ivanov}, nil)
+	req := httptest.NewRequest("POST", "/api/boards/0/tasks", strings.NewReader(`{"title": "написать бота"}`))
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, req)
+
+	var task apiTask
+	if err := json.NewDecoder(rec.Body).Decode(&task); err != nil {
+		t.Fatalf("decode error: %s", err)
+	}
+	if rec.Code != http.StatusCreated || task.Owner.ID != Ivanov {
+		t.Fatalf("task is created by the wrong user: %d %+v", rec.Code, task)
+	}
+}
+
+// TestAPISchema - в схеме описаны все поля задачи, которые отдает API
+func TestAPISchema(t *testing.T) {
+	var schema struct {
+		Defs map[string]struct {
+			Properties map[string]any `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(apiSchema, &schema); err != nil {
+		t.Fatalf("bad schema: %s", err)
+	}
+
+	for def, value := range map[string]any{
+		"task":       apiTask{},
+		"user":       apiUser{},
+		"resolution": apiResolution{},
+		"new_task":   apiNewTask{},
+		"assign":     apiAssign{},
+		"resolve":    apiResolve{},
+		"error":      apiError{},
+	} {
+		typ := reflect.TypeOf(value)
+		if len(schema.Defs[def].Properties) != typ.NumField() {
+			t.Errorf("schema %s has %d properties, %s has %d fields", def, len(schema.Defs[def].Properties), typ.Name(), typ.NumField())
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			if _, ok := schema.Defs[def].Properties[name]; !ok {
+				t.Errorf("schema %s has no property %s", def, name)
+			}
+		}
+	}
+}
+
+// TestAPIHooksAdminOnly - журнал вебхуков с адресами подписок видит только администратор бота
+func TestAPIHooksAdminOnly(t *testing.T) {
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.bootstrapAdmin = "ivanov"
+	manager.touchUser(Ivanov, "ivanov", "")
+	manager.touchUser(Petrov, "ppetrov", "")
+
+	hooks := newHookDispatcher(realClock{}, []hookSubscription{{URL: "http://localhost/hook?key=s3cret", Secret: "s3cret"}}, 1, time.Second)
+	api := newAPIHandler(manager, nil, map[string]int64{"ivanov-token": Ivanov, "petrov-token": Petrov}, hooks)
+
+	for token, want := range map[string]int{
+		"ivanov-token": http.StatusOK,
+		"petrov-token": http.StatusForbidden,
+	} {
+		req := httptest.NewRequest("GET", "/api/hooks", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("GET /api/hooks by %s: want status %d, have %d", token, want, rec.Code)
+		}
+	}
+}
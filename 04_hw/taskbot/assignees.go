@@ -0,0 +1,127 @@
+package main
+
+import (
+	"strings"
+)
+
+const (
+	msgAlreadyAssignee = "already_assignee"
+	msgAlreadyWatcher  = "already_watcher"
+	msgNotWatcher      = "not_watcher"
+	msgMe              = "me"
+	msgJoined          = "joined"
+	msgJoinedBy        = "joined_by"
+	msgWatching        = "watching"
+	msgUnwatched       = "unwatched"
+)
+
+func (t *Task) isAssignee(userID int64) bool {
+	return findUserIndex(t.Assignees, userID) >= 0
+}
+
+// hasOtherAssignees - есть ли у задачи исполнители кроме userID
+func hasOtherAssignees(task *Task, userID int64) bool {
+	for _, user := range task.Assignees {
+		if user.ID != userID {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Task) isWatcher(userID int64) bool {
+	return findUserIndex(t.Watchers, userID) >= 0
+}
+
+func findUserIndex(users []*User, userID int64) int {
+	for i, user := range users {
+		if user.ID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+func removeUser(users []*User, userID int64) []*User {
+	i := findUserIndex(users, userID)
+	if i < 0 {
+		return users
+	}
+	result := append(users[:i:i], users[i+1:]...)
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// formatUsers - список логинов, текущий пользователь показывается как "я"
+func formatUsers(users []*User, userID int64, lang string) string {
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		if user.ID == userID {
+			names = append(names, tr(lang, msgMe))
+		} else {
+			names = append(names, "@"+user.UserName)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// JoinTask добавляет actor к исполнителям задачи, не снимая остальных
+func (tm *TaskManager) JoinTask(boardID, taskID int64, actor *User) (AssigneeJoined, error) {
+	return change(tm, func() (AssigneeJoined, error) {
+		task, err := tm.openTask(boardID, taskID)
+		if err != nil {
+			return AssigneeJoined{}, err
+		}
+		if task.isAssignee(actor.ID) {
+			return AssigneeJoined{}, ErrAlreadyAssignee
+		}
+
+		task.Assignees = append(task.Assignees, &User{ID: actor.ID, UserName: actor.UserName})
+		if err := tm.saveTask(task); err != nil {
+			return AssigneeJoined{}, err
+		}
+
+		return AssigneeJoined{Task: *task, By: actor}, nil
+	})
+}
+
+// WatchTask подписывает actor на все изменения задачи
+func (tm *TaskManager) WatchTask(boardID, taskID int64, actor *User) (WatcherAdded, error) {
+	return change(tm, func() (WatcherAdded, error) {
+		task, err := tm.task(boardID, taskID)
+		if err != nil {
+			return WatcherAdded{}, err
+		}
+		if task.isWatcher(actor.ID) {
+			return WatcherAdded{}, ErrAlreadyWatcher
+		}
+
+		task.Watchers = append(task.Watchers, &User{ID: actor.ID, UserName: actor.UserName})
+		if err := tm.saveTask(task); err != nil {
+			return WatcherAdded{}, err
+		}
+
+		return WatcherAdded{Task: *task, By: actor}, nil
+	})
+}
+
+func (tm *TaskManager) UnwatchTask(boardID, taskID int64, actor *User) (WatcherRemoved, error) {
+	return change(tm, func() (WatcherRemoved, error) {
+		task, err := tm.task(boardID, taskID)
+		if err != nil {
+			return WatcherRemoved{}, err
+		}
+		if !task.isWatcher(actor.ID) {
+			return WatcherRemoved{}, ErrNotWatcher
+		}
+
+		task.Watchers = removeUser(task.Watchers, actor.ID)
+		if err := tm.saveTask(task); err != nil {
+			return WatcherRemoved{}, err
+		}
+
+		return WatcherRemoved{Task: *task, By: actor}, nil
+	})
+}
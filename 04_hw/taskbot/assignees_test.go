@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAssigneesAndWatchers(t *testing.T) {
+	tds, bot := newTestBot(t)
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+
+	cases := []testCase{
+		{Ivanov, "/new написать бота", map[int64]string{
+			Ivanov: `Задача "написать бота" создана, id=1`,
+		}},
+		{Alexandrov, "/watch_1", map[int64]string{
+			Alexandrov: `Вы следите за задачей "написать бота"`,
+		}},
+		{Alexandrov, "/watch_1", map[int64]string{
+			Alexandrov: ru(msgAlreadyWatcher),
+		}},
+		// наблюдатель узнает о назначении вместе с автором
+		{Petrov, "/assign_1", map[int64]string{
+			Petrov:     `Задача "написать бота" назначена на вас`,
+			Ivanov:     `Задача "написать бота" назначена на @ppetrov`,
+			Alexandrov: `Задача "написать бота" назначена на @ppetrov`,
+		}},
+		{Ivanov, "/join_1", map[int64]string{
+			Ivanov:     `Вы присоединились к задаче "написать бота"`,
+			Petrov:     `@ivanov теперь тоже исполнитель задачи "написать бота"`,
+			Alexandrov: `@ivanov теперь тоже исполнитель задачи "написать бота"`,
+		}},
+		{Ivanov, "/join_1", map[int64]string{
+			Ivanov: ru(msgAlreadyAssignee),
+		}},
+		{Petrov, "/tasks", map[int64]string{
+			Petrov: "1. написать бота by @ivanov\nassignees: я, @ivanov\nwatchers: @aalexandrov\n/unassign_1 /resolve_1",
+		}},
+		{Alexandrov, "/tasks", map[int64]string{
+			Alexandrov: "1. написать бота by @ivanov\nassignees: @ppetrov, @ivanov\nwatchers: я",
+		}},
+		{Petrov, "/my", map[int64]string{
+			Petrov: "1. написать бота by @ivanov\n/unassign_1 /resolve_1",
+		}},
+		{Alexandrov, "/leave_1", map[int64]string{
+			Alexandrov: ru(msgNotAssignee),
+		}},
+		// автор задачи уже получил уведомление как исполнитель, второго не будет
+		{Petrov, "/leave_1", map[int64]string{
+			Petrov:     ru(msgAccepted),
+			Ivanov:     `@ppetrov больше не исполнитель задачи "написать бота"`,
+			Alexandrov: `@ppetrov больше не исполнитель задачи "написать бота"`,
+		}},
+		{Alexandrov, "/unwatch_1", map[int64]string{
+			Alexandrov: `Вы больше не следите за задачей "написать бота"`,
+		}},
+		{Alexandrov, "/unwatch_1", map[int64]string{
+			Alexandrov: ru(msgNotWatcher),
+		}},
+		{Petrov, "/watch_1", map[int64]string{
+			Petrov: `Вы следите за задачей "написать бота"`,
+		}},
+		{Ivanov, "/unassign_1", map[int64]string{
+			Ivanov: ru(msgAccepted),
+			Petrov: `Задача "написать бота" осталась без исполнителя`,
+		}},
+		{Petrov, "/comment_1 возьмусь завтра", map[int64]string{
+			Petrov: `Комментарий к задаче "написать бота" добавлен`,
+			Ivanov: "@ppetrov к задаче \"написать бота\":\nвозьмусь завтра",
+		}},
+		{Ivanov, "/join_1", map[int64]string{
+			Ivanov: `Вы присоединились к задаче "написать бота"`,
+			Petrov: `@ivanov теперь тоже исполнитель задачи "написать бота"`,
+		}},
+		{Ivanov, "/resolve_1", map[int64]string{
+			Ivanov: `Задача "написать бота" выполнена`,
+			Petrov: `Задача "написать бота" выполнена @ivanov`,
+		}},
+	}
+
+	for idx, item := range cases {
+		tds.Lock()
+		tds.Answers = make(map[int64]string)
+		tds.Unlock()
+
+		handleUpdate(bot, manager, messageUpdate(item.user, item.command))
+
+		tds.Lock()
+		if !reflect.DeepEqual(tds.Answers, item.answers) {
+			t.Fatalf("[case%d, %d: %s] bad results:\n\tWant: %v\n\tHave: %v", idx, item.user, item.command, item.answers, tds.Answers)
+		}
+		tds.Unlock()
+	}
+}
+
+func TestLegacyAssigneeMigrated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.journal")
+
+	// журнал, записанный до появления нескольких исполнителей
+	journal := `{"op":"save","id":1,"task":{"ID":1,"Title":"написать бота","Assignee":{"ID":2,"UserName":"ppetrov"},"Owner":{"ID":1,"UserName":"ivanov"}}}` + "\n"
+	if err := os.WriteFile(path, []byte(journal), 0o644); err != nil {
+		t.Fatalf("write journal error: %s", err)
+	}
+
+	storage, err := OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("OpenFileStorage error: %s", err)
+	}
+	defer storage.Close()
+
+	task, _ := storage.Get(1)
+	want := []*User{{ID: 2, UserName: "ppetrov"}}
+	if !reflect.DeepEqual(task.Assignees, want) || task.LegacyAssignee != nil {
+		t.Fatalf("legacy assignee not migrated: %+v", task)
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	msgAuditHeader = "audit_header"
+	msgNoHistory   = "no_history"
+
+	msgActionCreated        = "action_created"
+	msgActionAssigned       = "action_assigned"
+	msgActionJoined         = "action_joined"
+	msgActionUnassigned     = "action_unassigned"
+	msgActionWatcherAdded   = "action_watcher_added"
+	msgActionWatcherRemoved = "action_watcher_removed"
+	msgActionResolved       = "action_resolved"
+	msgActionReopened       = "action_reopened"
+	msgActionCommented      = "action_commented"
+	msgActionTagsChanged    = "action_tags_changed"
+	msgActionDeleted        = "action_deleted"
+
+	msgFieldTitle       = "field_title"
+	msgFieldDescription = "field_description"
+	msgFieldDue         = "field_due"
+	msgFieldPriority    = "field_priority"
+	msgFieldTags        = "field_tags"
+	msgFieldAssignees   = "field_assignees"
+	msgFieldWatchers    = "field_watchers"
+	msgFieldResolution  = "field_resolution"
+	msgFieldComment     = "field_comment"
+)
+
+// поля задачи в журнале изменений
+const (
+	fieldTitle       = "title"
+	fieldDescription = "description"
+	fieldDue         = "due"
+	fieldPriority    = "priority"
+	fieldTags        = "tags"
+	fieldAssignees   = "assignees"
+	fieldWatchers    = "watchers"
+	fieldResolution  = "resolution"
+	fieldComment     = "comment"
+)
+
+var auditActions = map[string]string{
+	TaskCreated{}.Kind():    msgActionCreated,
+	TaskAssigned{}.Kind():   msgActionAssigned,
+	AssigneeJoined{}.Kind(): msgActionJoined,
+	TaskUnassigned{}.Kind(): msgActionUnassigned,
+	WatcherAdded{}.Kind():   msgActionWatcherAdded,
+	WatcherRemoved{}.Kind(): msgActionWatcherRemoved,
+	TaskResolved{}.Kind():   msgActionResolved,
+	TaskReopened{}.Kind():   msgActionReopened,
+	CommentAdded{}.Kind():   msgActionCommented,
+	TagsChanged{}.Kind():    msgActionTagsChanged,
+	TaskDeleted{}.Kind():    msgActionDeleted,
+}
+
+var auditFields = map[string]string{
+	fieldTitle:       msgFieldTitle,
+	fieldDescription: msgFieldDescription,
+	fieldDue:         msgFieldDue,
+	fieldPriority:    msgFieldPriority,
+	fieldTags:        msgFieldTags,
+	fieldAssignees:   msgFieldAssignees,
+	fieldWatchers:    msgFieldWatchers,
+	fieldResolution:  msgFieldResolution,
+	fieldComment:     msgFieldComment,
+}
+
+// AuditEntry - запись журнала изменений: кто, когда и что поменял в задаче.
+// Журнал только дописывается и выгружается как JSON lines
+type AuditEntry struct {
+	At      time.Time     `json:"at"`
+	TaskID  int64         `json:"task_id"`
+	BoardID int64         `json:"board_id"`
+	Action  string        `json:"action"`
+	Actor   *User         `json:"actor,omitempty"`
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// FieldChange - значение поля до и после изменения, пустая строка - значения не было
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// audit записывает событие в журнал. Вызывается из change под tm.mu:
+// задача до изменения берется из tm.snapshots, после - из события
+func (tm *TaskManager) audit(event Event) {
+	task, ok := eventTask(event)
+	if !ok {
+		return
+	}
+
+	var before, after *Task
+	if snapshot, ok := tm.snapshots[task.ID]; ok {
+		before = &snapshot
+	}
+	if _, deleted := event.(TaskDeleted); !deleted {
+		after = &task
+	}
+
+	entry := &AuditEntry{
+		At:      tm.clock.Now(),
+		TaskID:  task.ID,
+		BoardID: task.BoardID,
+		Action:  event.Kind(),
+		Changes: diffTasks(before, after),
+	}
+	if actor := eventActor(event); actor != nil {
+		entry.Actor = &User{ID: actor.ID, UserName: actor.UserName}
+	}
+
+	// изменение уже сохранено, поэтому ошибку журнала только логируем
+	if err := tm.storage.AppendAudit(entry); err != nil {
+		log.Printf("Ошибка записи в журнал изменений: %v", err)
+	}
+}
+
+// TaskHistory - журнал изменений задачи на доске, от старых записей к новым.
+// История удаленной задачи тоже доступна
+func (tm *TaskManager) TaskHistory(boardID, taskID int64) ([]AuditEntry, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	var history []AuditEntry
+	for _, entry := range tm.storage.Audit() {
+		if entry.TaskID == taskID && entry.BoardID == boardID {
+			history = append(history, *entry)
+		}
+	}
+	if len(history) > 0 {
+		return history, nil
+	}
+
+	if _, err := tm.task(boardID, taskID); err != nil {
+		return nil, err
+	}
+	// задача создана до того, как появился журнал
+	return nil, nil
+}
+
+// AuditLog - весь журнал изменений, для выгрузки
+func (tm *TaskManager) AuditLog() []AuditEntry {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	entries := tm.storage.Audit()
+	result := make([]AuditEntry, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, *entry)
+	}
+	return result
+}
+
+// diffTasks - поля, которые отличаются у задачи до и после изменения.
+// nil означает, что задачи не было (создание) или не стало (удаление)
+func diffTasks(before, after *Task) []FieldChange {
+	var changes []FieldChange
+
+	old, updated := auditValues(before), auditValues(after)
+	for i := range updated {
+		if old[i].value != updated[i].value {
+			changes = append(changes, FieldChange{
+				Field:  updated[i].field,
+				Before: old[i].value,
+				After:  updated[i].value,
+			})
+		}
+	}
+
+	// комментарии только добавляются, поэтому пишем в журнал новые
+	if after != nil {
+		seen := 0
+		if before != nil && len(before.Comments) <= len(after.Comments) {
+			seen = len(before.Comments)
+		}
+		for _, comment := range after.Comments[seen:] {
+			changes = append(changes, FieldChange{
+				Field: fieldComment,
+				After: "@" + comment.Author.UserName + ": " + comment.Text,
+			})
+		}
+	}
+
+	return changes
+}
+
+type auditValue struct {
+	field string
+	value string
+}
+
+// auditValues - поля задачи в том виде, в каком они пишутся в журнал
+func auditValues(task *Task) []auditValue {
+	var t Task
+	if task != nil {
+		t = *task
+	}
+
+	var due, resolution string
+	if t.Due != nil {
+		due = t.Due.Format("2006-01-02")
+	}
+	if current := t.resolution(); current != nil {
+		resolution = "@" + current.By.UserName
+		if current.Note != "" {
+			resolution += ": " + current.Note
+		}
+	}
+
+	return []auditValue{
+		{field: fieldTitle, value: t.Title},
+		{field: fieldDescription, value: t.Description},
+		{field: fieldDue, value: due},
+		{field: fieldPriority, value: t.Priority.name()},
+		{field: fieldTags, value: formatTags(t.Tags)},
+		{field: fieldAssignees, value: auditUsers(t.Assignees)},
+		{field: fieldWatchers, value: auditUsers(t.Watchers)},
+		{field: fieldResolution, value: resolution},
+	}
+}
+
+func auditUsers(users []*User) string {
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		names = append(names, "@"+user.UserName)
+	}
+	return strings.Join(names, " ")
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTaskLog(t *testing.T) {
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.clock = newFakeClock(time.Date(2026, 10, 17, 10, 0, 0, 0, time.Local))
+	manager.touchUser(Petrov, "ppetrov", "")
+	manager.touchBoard(personalBoardID, personalBoardTitle, Petrov)
+
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new написать бота !high")
+	manager.delegateTasks(personalBoardID, "assign_1", "@ppetrov", Ivanov, "ivanov")
+	// чужую задачу снять нельзя, в журнал это не попадает
+	manager.unassignTasks(personalBoardID, "unassign_1", Alexandrov, "aalexandrov")
+	manager.commentTasks(personalBoardID, "comment_1", "почти готово", Petrov, "ppetrov")
+	manager.resolveTasks(personalBoardID, "resolve_1", "готово", Petrov, "ppetrov")
+
+	want := `История задачи 1:
+17.10.2026 10:00 @ivanov: создание
+    название: — → написать бота
+    приоритет: — → high
+17.10.2026 10:00 @ivanov: назначение
+    исполнители: — → @ppetrov
+17.10.2026 10:00 @ppetrov: комментарий
+    комментарий: — → @ppetrov: почти готово
+17.10.2026 10:00 @ppetrov: выполнение
+    выполнение: — → @ppetrov: готово`
+	if have := manager.taskLog(personalBoardID, "log_1", Ivanov); have != want {
+		t.Fatalf("bad log:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+
+	if have := manager.taskLog(personalBoardID, "log_2", Ivanov); have != ru(msgLogNoTasks) {
+		t.Fatalf("log of unknown task: %s", have)
+	}
+	if have := manager.taskLog(-100, "log_1", Ivanov); have != ru(msgLogNoTasks) {
+		t.Fatalf("log of task from another board: %s", have)
+	}
+}
+
+// TestTaskLogActors - в истории у каждого изменения есть логин автора, в том числе у /tag и /leave
+func TestTaskLogActors(t *testing.T) {
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.clock = newFakeClock(time.Date(2026, 10, 17, 10, 0, 0, 0, time.Local))
+	manager.touchUser(Ivanov, "ivanov", "")
+	manager.touchUser(Petrov, "ppetrov", "")
+	manager.touchBoard(personalBoardID, personalBoardTitle, Ivanov)
+	manager.touchBoard(personalBoardID, personalBoardTitle, Petrov)
+
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new написать бота")
+	manager.tagTasks(personalBoardID, "tag_1", "backend", Ivanov, "ivanov")
+	manager.untagTasks(personalBoardID, "untag_1", "backend", Ivanov, "ivanov")
+	manager.delegateTasks(personalBoardID, "assign_1", "@ppetrov", Ivanov, "ivanov")
+	manager.leaveTasks(personalBoardID, "leave_1", Petrov, "ppetrov")
+	manager.watchTasks(personalBoardID, "watch_1", Petrov, "ppetrov")
+	manager.unwatchTasks(personalBoardID, "unwatch_1", Petrov, "ppetrov")
+
+	want := `История задачи 1:
+17.10.2026 10:00 @ivanov: создание
+    название: — → написать бота
+17.10.2026 10:00 @ivanov: изменение тегов
+    теги: — → #backend
+17.10.2026 10:00 @ivanov: изменение тегов
+    теги: #backend → —
+17.10.2026 10:00 @ivanov: назначение
+    исполнители: — → @ppetrov
+17.10.2026 10:00 @ppetrov: снятие исполнителя
+    исполнители: @ppetrov → —
+17.10.2026 10:00 @ppetrov: подписка
+    наблюдатели: — → @ppetrov
+17.10.2026 10:00 @ppetrov: отписка
+    наблюдатели: @ppetrov → —`
+	if have := manager.taskLog(personalBoardID, "log_1", Ivanov); have != want {
+		t.Fatalf("bad log:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+}
+
+func TestAuditExport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.journal")
+	storage, err := OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("OpenFileStorage error: %s", err)
+	}
+	manager := newTelegramPresenter(NewTaskManager(storage))
+	manager.touchUser(Ivanov, "ivanov", "")
+	manager.touchBoard(personalBoardID, personalBoardTitle, Ivanov)
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new первая")
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new вторая")
+	manager.tagTasks(personalBoardID, "tag_2", "backend", Ivanov, "ivanov")
+	storage.Close()
+
+	// журнал изменений переживает перезапуск
+	storage, err = OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("reopen error: %s", err)
+	}
+	defer storage.Close()
+	manager = newTelegramPresenter(NewTaskManager(storage))
+
+	api := newAPIHandler(manager, nil, map[string]int64{"token": Ivanov}, nil)
+	ts := httptest.NewServer(api)
+	defer ts.Close()
+
+	req, _ := http.NewRequest("GET", ts.URL+"/api/audit?task=2", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /api/audit error: %s", err)
+	}
+	defer resp.Body.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("bad line %q: %s", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) != 2 || entries[0].Action != "task_created" || entries[1].Action != "tags_changed" {
+		t.Fatalf("bad export: %+v", entries)
+	}
+	change := entries[1].Changes
+	if len(change) != 1 || change[0] != (FieldChange{Field: fieldTags, After: "#backend"}) {
+		t.Fatalf("bad tags change: %+v", change)
+	}
+}
+
+// TestAuditExportBoards - в выгрузку не попадают чужие доски и чужие задачи на личной доске
+func TestAuditExportBoards(t *testing.T) {
+	const group int64 = -100
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.touchUser(Ivanov, "ivanov", "")
+	manager.touchUser(Petrov, "ppetrov", "")
+	manager.touchBoard(personalBoardID, personalBoardTitle, Ivanov)
+	manager.touchBoard(personalBoardID, personalBoardTitle, Petrov)
+	manager.touchBoard(group, "backend", Petrov)
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new задача Иванова")
+	manager.addTasks(personalBoardID, Petrov, "ppetrov", "/new задача Петрова")
+	manager.addTasks(group, Petrov, "ppetrov", "/new задача группы")
+
+	api := newAPIHandler(manager, nil, map[string]int64{"ivanov-token": Ivanov, "petrov-token": Petrov}, nil)
+	ts := httptest.NewServer(api)
+	defer ts.Close()
+
+	export := func(token, query string, wantStatus int) []int64 {
+		t.Helper()
+		req, _ := http.NewRequest("GET", ts.URL+"/api/audit"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET /api/audit%s error: %s", query, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != wantStatus {
+			t.Fatalf("GET /api/audit%s: want status %d, have %d", query, wantStatus, resp.StatusCode)
+		}
+
+		var tasks []int64
+		scanner := bufio.NewScanner(resp.Body)
+		for wantStatus == http.StatusOK && scanner.Scan() {
+			var entry AuditEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				t.Fatalf("bad line %q: %s", scanner.Text(), err)
+			}
+			tasks = append(tasks, entry.TaskID)
+		}
+		return tasks
+	}
+
+	if have := export("ivanov-token", "", http.StatusOK); !reflect.DeepEqual(have, []int64{1}) {
+		t.Fatalf("ivanov sees tasks %v", have)
+	}
+	if have := export("petrov-token", "", http.StatusOK); !reflect.DeepEqual(have, []int64{2, 3}) {
+		t.Fatalf("petrov sees tasks %v", have)
+	}
+	if have := export("petrov-token", "?board=-100", http.StatusOK); !reflect.DeepEqual(have, []int64{3}) {
+		t.Fatalf("petrov sees tasks %v on the group board", have)
+	}
+	export("ivanov-token", "?board=-100", http.StatusNotFound)
+	export("ivanov-token", "?board=x", http.StatusNotFound)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"log"
+	"sort"
+
+	tgbotapi "github.com/skinass/telegram-bot-api/v5"
+)
+
+const (
+	// все личные чаты с ботом работают с одной общей доской
+	personalBoardID    int64 = 0
+	personalBoardTitle       = "Личные сообщения"
+
+	msgPersonalBoard = "personal_board"
+	msgNoBoards      = "no_boards"
+	msgBoards        = "boards"
+	msgBoardRow      = "board_row"
+)
+
+// Board - доска задач, у каждой группы с ботом она своя
+type Board struct {
+	ID      int64
+	Title   string
+	Members map[int64]bool
+	// роли участников, у кого роли нет - обычные участники. Первым администратором
+	// становится тот, кто начал работать с ботом в группе
+	Roles map[int64]BoardRole `json:",omitempty"`
+	// LegacyAdmins - администраторы из старых журналов, при чтении переносятся в Roles
+	LegacyAdmins map[int64]bool `json:"Admins,omitempty"`
+}
+
+// boardForChat - какой доской пользуются в чате
+func boardForChat(chat *tgbotapi.Chat) (int64, string) {
+	if chat == nil || chat.IsPrivate() {
+		return personalBoardID, personalBoardTitle
+	}
+	return chat.ID, chat.Title
+}
+
+// touchBoard запоминает, что пользователь работает с доской,
+// и обновляет ее название, если группу переименовали
+func (tm *TaskManager) touchBoard(boardID int64, title string, userID int64) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	board, ok := tm.storage.Board(boardID)
+	if !ok {
+		board = &Board{
+			ID:      boardID,
+			Members: make(map[int64]bool),
+		}
+		if boardID != personalBoardID {
+			board.Roles = map[int64]BoardRole{userID: BoardAdmin}
+		}
+	}
+	if ok && board.Title == title && board.Members[userID] {
+		return
+	}
+
+	board.Title = title
+	board.Members[userID] = true
+	if err := tm.storage.SaveBoard(board); err != nil {
+		log.Printf("Ошибка сохранения доски: %v", err)
+	}
+}
+
+// isMember - работал ли пользователь с доской. Вызывается под tm.mu
+func (tm *TaskManager) isMember(boardID, userID int64) bool {
+	board, ok := tm.storage.Board(boardID)
+	return ok && board.Members[userID]
+}
+
+// boardTitle - название доски на языке пользователя
+func boardTitle(boardID int64, title, lang string) string {
+	if boardID == personalBoardID {
+		return tr(lang, msgPersonalBoard)
+	}
+	return title
+}
+
+// BoardSummary - доска и число открытых задач на ней
+type BoardSummary struct {
+	ID        int64
+	Title     string
+	OpenTasks int
+}
+
+// Boards - доски, в которых участвует пользователь: сначала личная, потом по названию
+func (tm *TaskManager) Boards(userID int64) []BoardSummary {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	var boards []BoardSummary
+	for _, board := range tm.storage.Boards() {
+		if board.Members[userID] {
+			boards = append(boards, BoardSummary{
+				ID:        board.ID,
+				Title:     board.Title,
+				OpenTasks: len(tm.getBoardTasks(board.ID)),
+			})
+		}
+	}
+
+	sort.Slice(boards, func(i, j int) bool {
+		if boards[i].ID == personalBoardID || boards[j].ID == personalBoardID {
+			return boards[i].ID == personalBoardID
+		}
+		return boards[i].Title < boards[j].Title
+	})
+
+	return boards
+}
@@ -2,314 +2,556 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"sort"
-	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	tgbotapi "github.com/skinass/telegram-bot-api/v5"
 )
 
 const (
-	msgHelp = `
-		/tasks - посмотреть все задачи
-		/new XXX YYY ZZZ -создать новую задачу
-		/assign_$ID - сделать пользователя исполлнителем задачи
-		/unassign_$ID - удалить задачу у текущего исполнителя
-		/resolve_$ID - выполнить задачу, удалить ее из списка
-		/my - показать задачи, которые мне поручены
-		/owner - показать задачи, которые были созданы мной
-	`
-	msgGreeting       = "Привет! Я твой менеджер задач!"
-	msgNoTasks        = "Нет задач"
-	msgNotAssignee    = "Задача не на вас"
-	msgAccepted       = "Принято"
-	msgNoYourTasks    = "У вас нет задач"
-	msgNoCreatedTasks = "Вы не создавали задачи"
-	msgUnknownCommand = "Я не знаю такую команду"
-	msgLogNoTasks     = "Задачи не существует"
+	// id сообщений, тексты на разных языках лежат в messages_*.go
+	msgGreeting       = "greeting"
+	msgNoTasks        = "no_tasks"
+	msgNotAssignee    = "not_assignee"
+	msgAccepted       = "accepted"
+	msgNoYourTasks    = "no_your_tasks"
+	msgNoCreatedTasks = "no_created_tasks"
+	msgUnknownCommand = "unknown_command"
+	msgLogNoTasks     = "no_such_task"
+	msgStorageError   = "storage_error"
+	msgNoDoneTasks    = "no_done_tasks"
+	msgNotResolved    = "not_resolved"
+	msgEmptyTitle     = "empty_title"
+	msgBadDue         = "bad_due"
+	msgPage           = "page"
+	msgNextPage       = "next_page"
+	msgTaskCreated    = "task_created"
+	msgAssignedToYou  = "assigned_to_you"
+	msgUnassignedBy   = "unassigned_by"
+	msgNoAssigneeLeft = "no_assignee_left"
+	msgAssigneeLeft   = "assignee_left"
+	msgResolved       = "resolved"
+	msgResolvedBy     = "resolved_by"
+	msgResolutionNote = "resolution_note"
+	msgDoneBy         = "done_by"
+	msgReopened       = "reopened"
+	msgReopenedBy     = "reopened_by"
+
+	// сколько последних выполненных задач показывает /done
+	doneTasksLimit = 10
+	timeLayout     = "02.01.2006 15:04"
 )
 
 var (
-	BotToken   string
-	WebhookURL string
+	BotToken    string
+	AdminName   string
+	BotMode     string
+	WebhookURL  string
+	StoragePath string
+	Workers     int
+	PageSize    int
+
+	RemindEvery  time.Duration
+	RemindBefore time.Duration
+	PollTimeout  time.Duration
+
+	ShutdownTimeout time.Duration
+
+	ResolveRule  string
+	UnassignRule string
+	ReassignRule string
+
+	APITokens string
+
+	HooksPath     string
+	HooksAttempts int
+	HooksBackoff  time.Duration
 )
 
 func init() {
 	flag.StringVar(&BotToken, "tg.token", "", "token for telegram")
+	flag.StringVar(&AdminName, "tg.admin", "", "username of the bot admin, who is an admin of every board")
+	flag.StringVar(&BotMode, "tg.mode", modeWebhook, "how to receive updates: webhook or poll")
 	flag.StringVar(&WebhookURL, "tg.webhook", "", "webhook addr for telegram")
+	flag.DurationVar(&ShutdownTimeout, "tg.shutdown.timeout", 10*time.Second, "how long to wait for HTTP requests to finish on shutdown")
+	flag.DurationVar(&PollTimeout, "tg.poll.timeout", 30*time.Second, "long polling timeout for getUpdates in poll mode")
+	flag.StringVar(&StoragePath, "tg.storage", "", "path to tasks journal file, tasks are kept in memory if empty")
+	flag.IntVar(&Workers, "tg.workers", 4, "number of workers handling updates")
+	flag.IntVar(&PageSize, "tg.page", defaultPageSize, "how many tasks are shown on one page of /tasks, /my and /owner")
+	flag.DurationVar(&RemindEvery, "tg.remind.every", time.Minute, "how often due dates are checked")
+	flag.DurationVar(&RemindBefore, "tg.remind.before", 24*time.Hour, "how long before the deadline to remind")
+	flag.StringVar(&ResolveRule, "tg.resolve", defaultResolveRule, "who may resolve tasks: comma separated assignee, owner, admin, anyone if empty")
+	flag.StringVar(&UnassignRule, "tg.unassign", defaultUnassignRule, "who may unassign tasks: comma separated assignee, owner, admin, anyone if empty")
+	flag.StringVar(&ReassignRule, "tg.reassign", "", "who may take over a task from its assignees: comma separated assignee, owner, admin, anyone if empty")
+	flag.StringVar(&APITokens, "tg.api.tokens", "", "REST API tokens: comma separated token:userid, API is off if empty")
+	flag.StringVar(&HooksPath, "tg.hooks", "", "path to JSON file with outgoing webhook subscriptions")
+	flag.IntVar(&HooksAttempts, "tg.hooks.attempts", 5, "how many times to try delivering an outgoing webhook")
+	flag.DurationVar(&HooksBackoff, "tg.hooks.backoff", time.Second, "delay before the second delivery attempt, doubled for each next one")
 }
 
 type User struct {
 	ID       int64
 	UserName string
+	// Language - язык, выбранный через /lang, LanguageCode - язык телеграм-клиента
+	Language     string `json:",omitempty"`
+	LanguageCode string `json:",omitempty"`
+	// DigestAt - время ежедневной сводки (15:04), DigestSent - день последней отправленной
+	DigestAt   string `json:",omitempty"`
+	DigestSent string `json:",omitempty"`
 }
 type Task struct {
-	ID       int64
-	Title    string
-	Assignee *User
-	Owner    *User
+	ID          int64
+	BoardID     int64 `json:",omitempty"`
+	Title       string
+	Description string     `json:",omitempty"`
+	Due         *time.Time `json:",omitempty"`
+	Priority    Priority   `json:",omitempty"`
+	Tags        []string   `json:",omitempty"`
+	Assignees   []*User    `json:",omitempty"`
+	Watchers    []*User    `json:",omitempty"`
+	// Assignee - единственный исполнитель из старых журналов,
+	// при чтении журнала переносится в Assignees
+	LegacyAssignee *User `json:"Assignee,omitempty"`
+	Owner          *User
+	// Resolutions - все закрытия задачи по порядку, задача выполнена,
+	// если последнее из них не отменено через /reopen
+	Resolutions []Resolution `json:",omitempty"`
+	// Resolution - единственное закрытие из старых журналов,
+	// при чтении журнала переносится в Resolutions
+	LegacyResolution *Resolution `json:"Resolution,omitempty"`
+	Comments         []Comment   `json:",omitempty"`
+
+	// какие напоминания о сроке уже отправлены
+	DueReminded     bool `json:",omitempty"`
+	OverdueNotified bool `json:",omitempty"`
 }
 
-type TaskManager struct {
-	tasks  map[int64]*Task
-	lastID int64
-}
+// Resolution - кто, когда и с каким комментарием закрыл задачу
+// и кто потом вернул ее в работу
+type Resolution struct {
+	By   *User
+	At   time.Time
+	Note string `json:",omitempty"`
 
-func NewTaskManager() *TaskManager {
-	return &TaskManager{
-		tasks:  make(map[int64]*Task),
-		lastID: 1,
-	}
+	ReopenedBy *User      `json:",omitempty"`
+	ReopenedAt *time.Time `json:",omitempty"`
 }
 
-func (tm *TaskManager) getAllTasks(userID int64) string {
-	var myResponse string
-	rowsCount := 0
-
-	tasks := tm.getSortedTasks()
-	if len(tasks) > 0 {
-		for _, task := range tasks {
-			if rowsCount >= 1 {
-				myResponse += "\n\n"
-			}
-			myResponse += formatTaskResponse(*task, userID)
-			rowsCount++
-		}
-	} else {
-		myResponse = msgNoTasks
+// resolution - текущее закрытие задачи, nil если задача открыта
+func (t *Task) resolution() *Resolution {
+	if len(t.Resolutions) == 0 {
+		return nil
 	}
-
-	return myResponse
+	last := &t.Resolutions[len(t.Resolutions)-1]
+	if last.ReopenedBy != nil {
+		return nil
+	}
+	return last
 }
 
-func (tm *TaskManager) getOwnTasks(userID int64) string {
-	var myResponse string
-	found := false
-	rowsCount := 0
+func (t *Task) isResolved() bool {
+	return t.resolution() != nil
+}
 
-	tasks := tm.getSortedTasks()
-	for _, task := range tasks {
-		if task.Owner.ID == userID {
+// Clock позволяет подменить время в тестах
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
 
-			if rowsCount >= 1 {
-				myResponse += "\n\n"
-			}
-			myResponse += formatTaskResponse(*task, userID)
-			rowsCount++
-			found = true
-		}
-	}
-	if !found {
-		myResponse = msgNoCreatedTasks
-	}
+type realClock struct{}
 
-	return myResponse
+func (realClock) Now() time.Time {
+	return time.Now()
 }
 
-func (tm *TaskManager) getMyTasks(userID int64) string {
-	var myResponse string
-	found := false
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
 
-	tasks := tm.getSortedTasks()
-	for _, task := range tasks {
-		if task.Assignee != nil && task.Assignee.ID == userID {
-			myResponse += fmt.Sprintf("%d. %s by @%s\n/unassign_%d /resolve_%d",
-				task.ID, task.Title, task.Owner.UserName, task.ID, task.ID)
-			found = true
-		}
+// TaskManager можно вызывать из нескольких горутин, доступ к хранилищу
+// сериализуется мьютексом
+type TaskManager struct {
+	mu       sync.Mutex
+	storage  TaskStorage
+	clock    Clock
+	pageSize int
+
+	// кто может закрывать задачи, снимать с них исполнителей, назначать на них других
+	// и забирать задачи у других исполнителей (пустое правило - кто угодно)
+	resolveRule  accessRule
+	unassignRule accessRule
+	delegateRule accessRule
+	reassignRule accessRule
+	// логин администратора всех досок из -tg.admin
+	bootstrapAdmin string
+
+	subscribers []func(Event)
+	// snapshots - задачи до изменения, заполняется только внутри change
+	snapshots map[int64]Task
+}
 
+func NewTaskManager(storage TaskStorage) *TaskManager {
+	return &TaskManager{
+		storage:      storage,
+		clock:        realClock{},
+		pageSize:     defaultPageSize,
+		resolveRule:  mustParseAccessRule(defaultResolveRule),
+		unassignRule: mustParseAccessRule(defaultUnassignRule),
+		delegateRule: mustParseAccessRule(delegateRule),
 	}
-	if !found {
-		myResponse = msgNoYourTasks
-	}
-
-	return myResponse
 }
 
-func (tm *TaskManager) addTasks(userID int64, userName, commandText string) string {
-	var myResponse string
+func openStorage(path string) (TaskStorage, error) {
+	if path == "" {
+		return NewMemoryStorage(), nil
+	}
+	return OpenFileStorage(path)
+}
 
-	title := strings.TrimSpace(strings.TrimPrefix(commandText, "/new"))
+// change выполняет изменение под tm.mu и, если оно удалось,
+// записывает его в журнал изменений и рассылает событие подписчикам
+func change[E Event](tm *TaskManager, apply func() (E, error)) (E, error) {
+	tm.mu.Lock()
+	tm.snapshots = make(map[int64]Task)
+	event, err := apply()
+	if err == nil {
+		tm.audit(event)
+	}
+	tm.snapshots = nil
+	tm.mu.Unlock()
 
-	task := Task{
-		ID:    tm.lastID,
-		Title: title,
-		Owner: &User{
-			ID:       userID,
-			UserName: userName,
-		},
+	if err != nil {
+		return event, err
 	}
-	tm.tasks[tm.lastID] = &task
+	tm.publish(event)
+	return event, nil
+}
 
-	myResponse = fmt.Sprintf(`Задача "%s" создана, id=%d`, title, tm.lastID)
+// CreateTask создает задачу на доске, автор - owner
+func (tm *TaskManager) CreateTask(boardID int64, owner *User, fields newTaskFields) (TaskCreated, error) {
+	return change(tm, func() (TaskCreated, error) {
+		id, err := tm.storage.NextID()
+		if err != nil {
+			log.Printf("Ошибка получения id задачи: %v", err)
+			return TaskCreated{}, fmt.Errorf("%w: %v", ErrStorage, err)
+		}
 
-	tm.lastID++
-	return myResponse
+		task := &Task{
+			ID:          id,
+			BoardID:     boardID,
+			Title:       fields.Title,
+			Description: fields.Description,
+			Due:         fields.Due,
+			Priority:    fields.Priority,
+			Tags:        fields.Tags,
+			Owner: &User{
+				ID:       owner.ID,
+				UserName: owner.UserName,
+			},
+		}
+		if err := tm.saveTask(task); err != nil {
+			return TaskCreated{}, err
+		}
+
+		return TaskCreated{Task: *task}, nil
+	})
 }
 
-func (tm *TaskManager) assignTasks(text string, userID int64, userName string) (string, string, int64) {
-	var myResponse, ownerResponse string
-	var ownerReceiverID int64
+// AssignTask делает единственным исполнителем задачи пользователя userName,
+// а если логин не указан - самого actor
+func (tm *TaskManager) AssignTask(boardID, taskID int64, actor *User, userName string) (TaskAssigned, error) {
+	return change(tm, func() (TaskAssigned, error) {
+		assignee := actor
+		if userName != "" {
+			user, ok := tm.findUser(userName)
+			if !ok {
+				return TaskAssigned{}, &UnknownUserError{UserName: strings.TrimPrefix(strings.TrimSpace(userName), "@")}
+			}
+			// иначе в личку придет уведомление о задаче из чужой группы
+			if !tm.isMember(boardID, user.ID) {
+				return TaskAssigned{}, &NotMemberError{UserName: user.UserName}
+			}
+			assignee = user
+		}
 
-	task, _ := tm.getTaskByID(text)
+		task, err := tm.openTask(boardID, taskID)
+		if err != nil {
+			return TaskAssigned{}, err
+		}
+		// назначить задачу на другого может только автор или администратор доски,
+		// а забрать задачу у другого исполнителя - те, кого пускает -tg.reassign
+		switch {
+		case assignee.ID != actor.ID && !tm.allowed(tm.delegateRule, task, actor.ID):
+			return TaskAssigned{}, ErrForbidden
+		case hasOtherAssignees(task, actor.ID) && !tm.allowed(tm.reassignRule, task, actor.ID):
+			return TaskAssigned{}, ErrForbidden
+		}
 
-	if task == nil {
-		return "", "", -1
-	}
+		previous := task.Assignees
+		task.Assignees = []*User{{
+			ID:       assignee.ID,
+			UserName: assignee.UserName,
+		}}
+		if err := tm.saveTask(task); err != nil {
+			return TaskAssigned{}, err
+		}
 
-	if task.Assignee != nil {
-		ownerReceiverID = task.Assignee.ID
-	} else {
-		ownerReceiverID = task.Owner.ID
-	}
+		return TaskAssigned{Task: *task, By: actor, Previous: previous}, nil
+	})
+}
 
-	task.Assignee = &User{
-		ID:       userID,
-		UserName: userName,
-	}
+// UnassignTask снимает задачу с actor. Администратор доски (или другая роль,
+// разрешенная -tg.unassign) может снять с задачи всех исполнителей сразу
+func (tm *TaskManager) UnassignTask(boardID, taskID int64, actor *User) (TaskUnassigned, error) {
+	return change(tm, func() (TaskUnassigned, error) {
+		task, err := tm.openTask(boardID, taskID)
+		if err != nil {
+			return TaskUnassigned{}, err
+		}
 
-	myResponse = fmt.Sprintf(`Задача "%s" назначена на вас`, task.Title)
+		if !tm.allowed(tm.unassignRule, task, actor.ID) {
+			if !task.isAssignee(actor.ID) {
+				return TaskUnassigned{}, ErrNotAssignee
+			}
+			return TaskUnassigned{}, ErrForbidden
+		}
 
-	if userID != task.Owner.ID {
-		ownerResponse = fmt.Sprintf(`Задача "%s" назначена на @%s`, task.Title, userName)
-	}
+		if task.isAssignee(actor.ID) {
+			return tm.removeAssignee(task, actor)
+		}
+		if len(task.Assignees) == 0 {
+			return TaskUnassigned{}, ErrNoAssignees
+		}
 
-	return myResponse, ownerResponse, ownerReceiverID
+		removed := task.Assignees
+		task.Assignees = nil
+		if err := tm.saveTask(task); err != nil {
+			return TaskUnassigned{}, err
+		}
+
+		return TaskUnassigned{Task: *task, By: actor, Removed: removed}, nil
+	})
 }
 
-func (tm *TaskManager) unassignTasks(text string, userID int64) (string, string, int64) {
-	var myResponse, ownerResponse string
-	var ownerReceiverID int64
+// LeaveTask снимает с задачи только самого actor
+func (tm *TaskManager) LeaveTask(boardID, taskID int64, actor *User) (TaskUnassigned, error) {
+	return change(tm, func() (TaskUnassigned, error) {
+		task, err := tm.openTask(boardID, taskID)
+		if err != nil {
+			return TaskUnassigned{}, err
+		}
+		if !task.isAssignee(actor.ID) {
+			return TaskUnassigned{}, ErrNotAssignee
+		}
+		if !tm.allowed(tm.unassignRule, task, actor.ID) {
+			return TaskUnassigned{}, ErrForbidden
+		}
 
-	task, _ := tm.getTaskByID(text)
+		return tm.removeAssignee(task, actor)
+	})
+}
 
-	if task == nil {
-		return "", "", 0
+// removeAssignee убирает пользователя из исполнителей, остальные остаются.
+// Вызывается под tm.mu
+func (tm *TaskManager) removeAssignee(task *Task, actor *User) (TaskUnassigned, error) {
+	leaving := task.Assignees[findUserIndex(task.Assignees, actor.ID)]
+	task.Assignees = removeUser(task.Assignees, actor.ID)
+	if err := tm.saveTask(task); err != nil {
+		return TaskUnassigned{}, err
 	}
 
-	ownerReceiverID = task.Owner.ID
+	return TaskUnassigned{Task: *task, By: actor, Removed: []*User{leaving}}, nil
+}
 
-	if userID != task.Assignee.ID {
-		myResponse = msgNotAssignee
-	} else {
-		task.Assignee = nil
-		myResponse = msgAccepted
+// ResolveTask закрывает задачу с необязательным комментарием
+func (tm *TaskManager) ResolveTask(boardID, taskID int64, actor *User, note string) (TaskResolved, error) {
+	return change(tm, func() (TaskResolved, error) {
+		task, err := tm.openTask(boardID, taskID)
+		if err != nil {
+			return TaskResolved{}, err
+		}
+		if !tm.allowed(tm.resolveRule, task, actor.ID) {
+			return TaskResolved{}, ErrForbidden
+		}
 
-		ownerResponse = fmt.Sprintf(`Задача "%s" осталась без исполнителя`, task.Title)
-	}
+		task.Resolutions = append(task.Resolutions, Resolution{
+			By: &User{
+				ID:       actor.ID,
+				UserName: actor.UserName,
+			},
+			At:   tm.clock.Now(),
+			Note: strings.TrimSpace(note),
+		})
+		if err := tm.saveTask(task); err != nil {
+			return TaskResolved{}, err
+		}
 
-	return myResponse, ownerResponse, ownerReceiverID
+		return TaskResolved{Task: *task, By: actor}, nil
+	})
 }
 
-func (tm *TaskManager) resolveTasks(text string, userID int64, userName string) (string, string, int64) {
-	var myResponse, ownerResponse string
-	var ownerReceiverID int64
+// ReopenTask возвращает выполненную задачу в список, автор остается прежним,
+// а исполнителя нужно назначить заново
+func (tm *TaskManager) ReopenTask(boardID, taskID int64, actor *User) (TaskReopened, error) {
+	return change(tm, func() (TaskReopened, error) {
+		task, err := tm.task(boardID, taskID)
+		if err != nil {
+			return TaskReopened{}, err
+		}
+		if !task.isResolved() {
+			return TaskReopened{}, ErrNotResolved
+		}
 
-	task, id := tm.getTaskByID(text)
+		// прошлые закрытия остаются в истории, последнее помечаем отмененным
+		now := tm.clock.Now()
+		resolutions := append([]Resolution(nil), task.Resolutions...)
+		last := &resolutions[len(resolutions)-1]
+		last.ReopenedBy = &User{ID: actor.ID, UserName: actor.UserName}
+		last.ReopenedAt = &now
+		task.Resolutions = resolutions
+		task.Assignees = nil
+		// о сроке открытой заново задачи напоминаем заново
+		task.DueReminded = false
+		task.OverdueNotified = false
+		if err := tm.saveTask(task); err != nil {
+			return TaskReopened{}, err
+		}
 
-	if task == nil {
-		return "", "", 0
-	}
+		return TaskReopened{Task: *task, By: actor}, nil
+	})
+}
 
-	ownerReceiverID = task.Owner.ID
-	taskTitle := task.Title
+// Task - задача с доски, выполненная или нет
+func (tm *TaskManager) Task(boardID, taskID int64) (Task, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
 
-	delete(tm.tasks, id)
+	task, err := tm.task(boardID, taskID)
+	if err != nil {
+		return Task{}, err
+	}
+	return *task, nil
+}
 
-	myResponse = fmt.Sprintf(`Задача "%s" выполнена`, taskTitle)
+// OpenTasks - невыполненные задачи доски по порядку id
+func (tm *TaskManager) OpenTasks(boardID int64) []Task {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
 
-	if userID != ownerReceiverID {
-		ownerResponse = fmt.Sprintf(`Задача "%s" выполнена @%s`, taskTitle, userName)
+	tasks := tm.getBoardTasks(boardID)
+	result := make([]Task, 0, len(tasks))
+	for _, task := range tasks {
+		result = append(result, *task)
 	}
-
-	return myResponse, ownerResponse, ownerReceiverID
+	return result
 }
 
-func (tm *TaskManager) getTaskByID(text string) (*Task, int64) {
-	parts := strings.Split(text, "_")
-	if len(parts) < 2 {
-		log.Println("Некорректный формат команды — не найден ID")
-		return nil, 0
-	}
+// DoneTasks - последние выполненные задачи доски, сначала самые свежие
+func (tm *TaskManager) DoneTasks(boardID int64, limit int) []Task {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
 
-	assignID, err := strconv.Atoi(parts[1])
-	if err != nil {
-		log.Printf("Ошибка конвертации string to int: %v", err)
+	var done []Task
+	for _, task := range tm.getSortedTasks() {
+		if task.BoardID == boardID && task.isResolved() {
+			done = append(done, *task)
+		}
 	}
 
-	id := int64(assignID)
-	task, taskExists := tm.tasks[id]
-
-	if !taskExists {
-		log.Println(msgLogNoTasks)
-		return nil, 0
+	sort.SliceStable(done, func(i, j int) bool {
+		return done[i].resolution().At.After(done[j].resolution().At)
+	})
+	if len(done) > limit {
+		done = done[:limit]
 	}
 
-	return task, id
+	return done
 }
 
 func (tm *TaskManager) getSortedTasks() []*Task {
-	tasks := make([]*Task, 0, len(tm.tasks))
-	for _, task := range tm.tasks {
-		tasks = append(tasks, task)
-	}
+	return tm.storage.List()
+}
 
-	sort.Slice(tasks, func(i, j int) bool {
-		return tasks[i].ID < tasks[j].ID
-	})
+func (tm *TaskManager) getOpenTasks() []*Task {
+	var tasks []*Task
+	for _, task := range tm.getSortedTasks() {
+		if !task.isResolved() {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
 
+func (tm *TaskManager) getBoardTasks(boardID int64) []*Task {
+	var tasks []*Task
+	for _, task := range tm.getOpenTasks() {
+		if task.BoardID == boardID {
+			tasks = append(tasks, task)
+		}
+	}
 	return tasks
 }
 
-func formatTaskResponse(task Task, userID int64) string {
-	if task.Assignee == nil {
-		return fmt.Sprintf(
-			"%d. %s by @%s\n/assign_%d",
-			task.ID,
-			task.Title,
-			task.Owner.UserName,
-			task.ID,
-		)
-	}
-	if task.Assignee.ID == userID {
-		return fmt.Sprintf(
-			"%d. %s by @%s\nassignee: я\n/unassign_%d /resolve_%d",
-			task.ID,
-			task.Title,
-			task.Owner.UserName,
-			task.ID,
-			task.ID,
-		)
-	}
-
-	return fmt.Sprintf(
-		"%d. %s by @%s\nassignee: @%s",
+// commandArgs отрезает от текста сообщения саму команду (/new или /new@bot)
+func commandArgs(text string) string {
+	i := strings.IndexAny(text, " \n")
+	if i < 0 {
+		return ""
+	}
+	return text[i:]
+}
+
+func formatTaskResponse(task Task, userID int64, lang string) string {
+	myResponse := fmt.Sprintf(
+		"%d. %s by @%s%s",
 		task.ID,
 		task.Title,
 		task.Owner.UserName,
-		task.Assignee.UserName,
+		formatTaskDetails(task, lang),
 	)
-}
 
-func startHTTPServer() {
-	http.HandleFunc("/state", func(w http.ResponseWriter, r *http.Request) {
-		if _, err := w.Write([]byte("all is working")); err != nil {
-			log.Printf("Ошибка записи в ResponseWriter: %v", err)
-		}
-	})
+	switch len(task.Assignees) {
+	case 0:
+	case 1:
+		myResponse += "\nassignee: " + formatUsers(task.Assignees, userID, lang)
+	default:
+		myResponse += "\nassignees: " + formatUsers(task.Assignees, userID, lang)
+	}
+	if len(task.Watchers) > 0 {
+		myResponse += "\nwatchers: " + formatUsers(task.Watchers, userID, lang)
+	}
+
+	switch {
+	case len(task.Assignees) == 0:
+		myResponse += fmt.Sprintf("\n/assign_%d", task.ID)
+	case task.isAssignee(userID):
+		myResponse += fmt.Sprintf("\n/unassign_%d /resolve_%d", task.ID, task.ID)
+	}
+
+	return myResponse
+}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8081"
+func formatDoneTaskResponse(task Task, lang string) string {
+	resp := fmt.Sprintf("%d. %s by @%s", task.ID, task.Title, task.Owner.UserName)
+	resolution := task.resolution()
+	resp += tr(lang, msgDoneBy, resolution.By.UserName, resolution.At.Format(timeLayout))
+	if resolution.Note != "" {
+		resp += tr(lang, msgResolutionNote, resolution.Note)
 	}
 
-	log.Fatalln("http err:", http.ListenAndServe(":"+port, nil))
-	fmt.Println("start listen :" + port)
+	return resp + fmt.Sprintf("\n/reopen_%d", task.ID)
 }
 
 func setupWebhook(bot *tgbotapi.BotAPI) error {
@@ -325,107 +567,288 @@ func setupWebhook(bot *tgbotapi.BotAPI) error {
 	return nil
 }
 
+// startTaskBot работает, пока не отменят ctx. После отмены бот перестает
+// принимать апдейты, дообрабатывает уже полученные и закрывает хранилище
 func startTaskBot(ctx context.Context) error {
 	// сюда пишите ваш код
+	mode, shutdownTimeout := BotMode, ShutdownTimeout
+	if mode != modeWebhook && mode != modePoll {
+		return fmt.Errorf("unknown -tg.mode %q, use webhook or poll", mode)
+	}
+	apiTokens, err := parseAPITokens(APITokens)
+	if err != nil {
+		return fmt.Errorf("bad -tg.api.tokens: %w", err)
+	}
+	var subscriptions []hookSubscription
+	if HooksPath != "" {
+		if subscriptions, err = loadHookSubscriptions(HooksPath); err != nil {
+			return fmt.Errorf("bad -tg.hooks: %w", err)
+		}
+	}
+
 	bot, err := tgbotapi.NewBotAPI(BotToken)
 	if err != nil {
-		log.Fatalf("NewBotAPI failed: %s", err)
 		return fmt.Errorf("NewBotAPI failed: %w", err)
 	}
 
 	bot.Debug = true
 	fmt.Printf("Authorized on account %s\n", bot.Self.UserName)
 
-	if err := setupWebhook(bot); err != nil {
-		log.Fatalf("Webhook setup failed: %s", err)
+	storage, err := openStorage(StoragePath)
+	if err != nil {
+		return fmt.Errorf("open storage failed: %w", err)
 	}
 
-	go startHTTPServer()
+	manager := NewTaskManager(storage)
+	manager.bootstrapAdmin = AdminName
+	if PageSize > 0 {
+		manager.pageSize = PageSize
+	}
+	if manager.resolveRule, err = parseAccessRule(ResolveRule); err != nil {
+		storage.Close()
+		return fmt.Errorf("bad -tg.resolve: %w", err)
+	}
+	if manager.unassignRule, err = parseAccessRule(UnassignRule); err != nil {
+		storage.Close()
+		return fmt.Errorf("bad -tg.unassign: %w", err)
+	}
+	if manager.reassignRule, err = parseAccessRule(ReassignRule); err != nil {
+		storage.Close()
+		return fmt.Errorf("bad -tg.reassign: %w", err)
+	}
+	presenter := newTelegramPresenter(manager)
 
-	// updateConfig := tgbotapi.NewUpdate(0)
-	// updateConfig.Timeout = 60
+	var hooks *hookDispatcher
+	if len(subscriptions) > 0 {
+		hooks = newHookDispatcher(manager.clock, subscriptions, HooksAttempts, HooksBackoff)
+		manager.Subscribe(hooks.handle)
+	}
 
-	// updates := bot.GetUpdatesChan(updateConfig)
+	var (
+		updates tgbotapi.UpdatesChannel
+		webhook *webhookReceiver
+		api     http.Handler
+		server  *http.Server
+		status  botStatus
+	)
+	if len(apiTokens) > 0 {
+		api = newAPIHandler(presenter, bot, apiTokens, hooks)
+	}
+	if mode == modePoll {
+		// публичный адрес не нужен, апдейты забираем сами
+		if updates, err = startPolling(ctx, bot, manager); err != nil {
+			storage.Close()
+			return fmt.Errorf("start polling failed: %w", err)
+		}
+		server = newHTTPServer(httpAddr(), manager, &status, api, nil)
+	} else {
+		if err := setupWebhook(bot); err != nil {
+			storage.Close()
+			return fmt.Errorf("webhook setup failed: %w", err)
+		}
+		webhook = newWebhookReceiver(bot.Buffer)
+		updates = webhook.updates
+		server = newHTTPServer(httpAddr(), manager, &status, api, webhook)
+	}
 
-	updates := bot.ListenForWebhook("/")
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		if mode == modePoll {
+			bot.StopReceivingUpdates()
+		}
+		storage.Close()
+		return fmt.Errorf("listen %s failed: %w", server.Addr, err)
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Ошибка HTTP-сервера: %v", err)
+		}
+	}()
 
-	manager := NewTaskManager()
+	var reminders sync.WaitGroup
+	reminders.Add(2)
+	status.receiving.Store(true)
+	if hooks != nil {
+		hooks.start(ctx)
+	}
 
-	// Создаём канал для завершения работы
-	done := make(chan struct{})
+	go func() {
+		defer reminders.Done()
+		runReminders(ctx, bot, presenter, RemindEvery, RemindBefore)
+	}()
+	go func() {
+		defer reminders.Done()
+		runDigests(ctx, bot, presenter, RemindEvery)
+	}()
 
+	// stopped закрывается, когда HTTP-сервер остановлен и его обработчики,
+	// в том числе запросы API, больше не трогают хранилище
+	stopped := make(chan struct{})
 	go func() {
+		defer close(stopped)
 		<-ctx.Done()
 		fmt.Println("Получен сигнал завершения, останавливаем бота...")
-		close(done)
+		status.receiving.Store(false)
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Ошибка остановки HTTP-сервера: %v", err)
+			server.Close()
+		}
 
+		// после этого канал апдейтов закроется и воркеры доделают то, что уже получено
+		if mode == modePoll {
+			bot.StopReceivingUpdates()
+		} else {
+			webhook.close()
+		}
 	}()
 
-	for update := range updates {
-		if update.Message != nil {
+	runWorkers(updates, Workers, func(update tgbotapi.Update) {
+		handleUpdate(bot, presenter, update)
+	})
+	reminders.Wait()
+	<-stopped
+	if hooks != nil {
+		// новых событий больше не будет, дожидаемся отправки уже случившихся
+		hooks.close()
+	}
 
-			log.Printf("[%s] %s", update.Message.From.UserName, update.Message.Text)
-			var myResponse, ownerResponse string
-			var msg, ownerMsg tgbotapi.MessageConfig
-			var receiverID, ownerReceiverID int64
+	if err := storage.Close(); err != nil {
+		return fmt.Errorf("close storage failed: %w", err)
+	}
 
-			userID := update.Message.From.ID
-			userName := update.Message.From.UserName
-			text := update.Message.Command()
-			receiverID = update.Message.Chat.ID
-			switch {
-			case text == "start":
-				myResponse = msgGreeting
+	fmt.Println("Бот завершает работу")
+	return nil
+}
 
-			case text == "help":
-				myResponse = fmt.Sprintf("Вот мои команды: %s", msgHelp)
+func handleUpdate(bot *tgbotapi.BotAPI, p *telegramPresenter, update tgbotapi.Update) {
+	defer func(start time.Time) {
+		metrics.observeUpdate(updateCommand(update), time.Since(start))
+	}(time.Now())
 
-			case text == "tasks":
-				myResponse = manager.getAllTasks(userID)
+	if update.CallbackQuery != nil {
+		handleCallback(bot, p, update.CallbackQuery)
+		return
+	}
+	if update.Message == nil {
+		return
+	}
 
-			case text == "owner":
-				myResponse = manager.getOwnTasks(userID)
+	log.Printf("[%s] %s", update.Message.From.UserName, update.Message.Text)
 
-			case text == "my":
-				myResponse = manager.getMyTasks(userID)
+	boardID, boardTitle := boardForChat(update.Message.Chat)
+	c := commandContext{
+		BoardID:  boardID,
+		UserID:   update.Message.From.ID,
+		UserName: update.Message.From.UserName,
+		Command:  update.Message.Command(),
+		Args:     update.Message.CommandArguments(),
+		Text:     update.Message.Text,
+	}
+	p.touchBoard(boardID, boardTitle, c.UserID)
+	p.touchUser(c.UserID, c.UserName, update.Message.From.LanguageCode)
+	c.Lang = p.languageOf(c.UserID)
 
-			case strings.HasPrefix(text, "new"):
-				myResponse = manager.addTasks(userID, userName, update.Message.Text)
+	reply := runCommand(p, c)
 
-			case strings.HasPrefix(text, "assign"):
-				myResponse, ownerResponse, ownerReceiverID = manager.assignTasks(text, userID, userName)
+	sendText(bot, update.Message.Chat.ID, reply.Text, reply.Keyboard)
+	for _, n := range reply.Notifications {
+		notify(bot, n.ChatID, n.Text)
+	}
+}
 
-			case strings.HasPrefix(text, "unassign"):
-				myResponse, ownerResponse, ownerReceiverID = manager.unassignTasks(text, userID)
+// runCommand находит команду в реестре и проверяет, что пользователю можно ее выполнить
+func runCommand(p *telegramPresenter, c commandContext) commandReply {
+	cmd, ok := botCommands.lookup(c.Command)
+	if !ok {
+		return textReply(tr(c.Lang, msgUnknownCommand))
+	}
+	if !cmd.ReadOnly && !p.canWrite(c.BoardID, c.UserID) {
+		return textReply(tr(c.Lang, msgReadOnly))
+	}
+	return cmd.Handle(p, c)
+}
 
-			case strings.HasPrefix(text, "resolve"):
-				myResponse, ownerResponse, ownerReceiverID = manager.resolveTasks(text, userID, userName)
+func notify(bot Sender, chatID int64, text string) {
+	for _, chunk := range splitMessage(text, maxMessageLength) {
+		if _, err := bot.Send(tgbotapi.NewMessage(chatID, chunk)); err != nil {
+			log.Printf("Ошибка отправки уведомления: %v", err)
+			metrics.sendFailed(sendNotification)
+		}
+	}
+}
 
-			default:
-				myResponse = msgUnknownCommand
+// runWorkers раздает апдейты воркерам. Апдейты из одного чата обрабатываются
+// по очереди, так что порядок команд в чате сохраняется, а одновременно
+// работают не больше workers обработчиков. Очередь каждого чата своя и не ограничена,
+// поэтому медленная отправка в одном чате не задерживает прием апдейтов из остальных.
+func runWorkers(updates <-chan tgbotapi.Update, workers int, handle func(tgbotapi.Update)) {
+	if workers < 1 {
+		workers = 1
+	}
 
-			}
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+		// pending - очереди чатов, у которых сейчас есть свой обработчик
+		pending = make(map[int64][]tgbotapi.Update)
+		slots   = make(chan struct{}, workers)
+	)
 
-			msg = tgbotapi.NewMessage(receiverID, myResponse)
-			if _, err := bot.Send(msg); err != nil {
-				log.Printf("Ошибка отправки сообщения: %v", err)
-			}
-			if ownerResponse != "" {
-				ownerMsg = tgbotapi.NewMessage(ownerReceiverID, ownerResponse)
-				if _, err := bot.Send(ownerMsg); err != nil {
-					log.Printf("Ошибка отправки сообщения владельцу: %v", err)
-				}
+	drain := func(chatID int64) {
+		defer wg.Done()
+		for {
+			mu.Lock()
+			queue := pending[chatID]
+			if len(queue) == 0 {
+				delete(pending, chatID)
+				mu.Unlock()
+				return
 			}
+			update := queue[0]
+			pending[chatID] = queue[1:]
+			mu.Unlock()
+
+			slots <- struct{}{}
+			handle(update)
+			<-slots
 		}
 	}
 
-	fmt.Println("Бот завершает работу")
-	return nil
+	for update := range updates {
+		chatID := updateChatID(update)
+
+		mu.Lock()
+		queue, running := pending[chatID]
+		pending[chatID] = append(queue, update)
+		mu.Unlock()
+
+		if !running {
+			wg.Add(1)
+			go drain(chatID)
+		}
+	}
+
+	wg.Wait()
+}
+
+// updateChatID - чат, из которого пришло сообщение или нажатие кнопки
+func updateChatID(update tgbotapi.Update) int64 {
+	switch {
+	case update.Message != nil && update.Message.Chat != nil:
+		return update.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil && update.CallbackQuery.Message.Chat != nil:
+		return update.CallbackQuery.Message.Chat.ID
+	}
+	return 0
 }
 
 func main() {
 	flag.Parse()
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	err := startTaskBot(ctx)
 	if err != nil {
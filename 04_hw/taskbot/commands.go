@@ -0,0 +1,275 @@
+package main
+
+// id справки по командам для /help
+const (
+	msgHelpIntro    = "help_intro"
+	msgHelpHelp     = "help_help"
+	msgHelpTasks    = "help_tasks"
+	msgHelpNew      = "help_new"
+	msgHelpAssign   = "help_assign"
+	msgHelpUnassign = "help_unassign"
+	msgHelpJoin     = "help_join"
+	msgHelpLeave    = "help_leave"
+	msgHelpWatch    = "help_watch"
+	msgHelpUnwatch  = "help_unwatch"
+	msgHelpResolve  = "help_resolve"
+	msgHelpReopen   = "help_reopen"
+	msgHelpTag      = "help_tag"
+	msgHelpUntag    = "help_untag"
+	msgHelpComment  = "help_comment"
+	msgHelpShow     = "help_show"
+	msgHelpLog      = "help_log"
+	msgHelpMy       = "help_my"
+	msgHelpOwner    = "help_owner"
+	msgHelpDone     = "help_done"
+	msgHelpFind     = "help_find"
+	msgHelpBoards   = "help_boards"
+	msgHelpRole     = "help_role"
+	msgHelpAdmin    = "help_admin"
+	msgHelpDelete   = "help_delete"
+	msgHelpLang     = "help_lang"
+	msgHelpDigest   = "help_digest"
+
+	msgArgsList    = "args_list"
+	msgArgsNew     = "args_new"
+	msgArgsMention = "args_mention"
+	msgArgsAssign  = "args_assign"
+	msgArgsNote    = "args_note"
+	msgArgsTag     = "args_tag"
+	msgArgsText    = "args_text"
+	msgArgsFind    = "args_find"
+	msgArgsRole    = "args_role"
+	msgArgsLang    = "args_lang"
+	msgArgsDigest  = "args_digest"
+)
+
+// botCommands - все команды бота, порядок регистрации - порядок в /help
+var botCommands = newCommandRouter()
+
+func init() {
+	botCommands.register(command{
+		Name:     "start",
+		ReadOnly: true,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return textReply(tr(c.Lang, msgGreeting))
+		},
+	})
+	botCommands.register(command{
+		Name:     "help",
+		Help:     msgHelpHelp,
+		ReadOnly: true,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return textReply(tr(c.Lang, msgHelpIntro) + "\n" + botCommands.help(c.Lang))
+		},
+	})
+	botCommands.register(listCommand(viewAll, msgHelpTasks))
+	botCommands.register(command{
+		Name: "new",
+		Args: msgArgsNew,
+		Help: msgHelpNew,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return textReply(p.addTasks(c.BoardID, c.UserID, c.UserName, c.Text))
+		},
+	})
+	botCommands.register(command{
+		Name:   "assign",
+		WithID: true,
+		Args:   msgArgsAssign,
+		Help:   msgHelpAssign,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			if c.Args != "" {
+				return notifyReply(p.delegateTasks(c.BoardID, c.Command, c.Args, c.UserID, c.UserName))
+			}
+			return notifyReply(p.assignTasks(c.BoardID, c.Command, c.UserID, c.UserName))
+		},
+	})
+	botCommands.register(command{
+		Name:   "unassign",
+		WithID: true,
+		Help:   msgHelpUnassign,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return notifyReply(p.unassignTasks(c.BoardID, c.Command, c.UserID, c.UserName))
+		},
+	})
+	botCommands.register(command{
+		Name:   "join",
+		WithID: true,
+		Help:   msgHelpJoin,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return notifyReply(p.joinTasks(c.BoardID, c.Command, c.UserID, c.UserName))
+		},
+	})
+	botCommands.register(command{
+		Name:   "leave",
+		WithID: true,
+		Help:   msgHelpLeave,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return notifyReply(p.leaveTasks(c.BoardID, c.Command, c.UserID, c.UserName))
+		},
+	})
+	botCommands.register(command{
+		Name:     "watch",
+		WithID:   true,
+		Help:     msgHelpWatch,
+		ReadOnly: true,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return textReply(p.watchTasks(c.BoardID, c.Command, c.UserID, c.UserName))
+		},
+	})
+	botCommands.register(command{
+		Name:     "unwatch",
+		WithID:   true,
+		Help:     msgHelpUnwatch,
+		ReadOnly: true,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return textReply(p.unwatchTasks(c.BoardID, c.Command, c.UserID, c.UserName))
+		},
+	})
+	botCommands.register(command{
+		Name:   "resolve",
+		WithID: true,
+		Args:   msgArgsNote,
+		Help:   msgHelpResolve,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return notifyReply(p.resolveTasks(c.BoardID, c.Command, c.Args, c.UserID, c.UserName))
+		},
+	})
+	botCommands.register(command{
+		Name:   "reopen",
+		WithID: true,
+		Help:   msgHelpReopen,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return notifyReply(p.reopenTasks(c.BoardID, c.Command, c.UserID, c.UserName))
+		},
+	})
+	botCommands.register(command{
+		Name:   "tag",
+		WithID: true,
+		Args:   msgArgsTag,
+		Help:   msgHelpTag,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return textReply(p.tagTasks(c.BoardID, c.Command, c.Args, c.UserID, c.UserName))
+		},
+	})
+	botCommands.register(command{
+		Name:   "untag",
+		WithID: true,
+		Args:   msgArgsTag,
+		Help:   msgHelpUntag,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return textReply(p.untagTasks(c.BoardID, c.Command, c.Args, c.UserID, c.UserName))
+		},
+	})
+	botCommands.register(command{
+		Name:   "comment",
+		WithID: true,
+		Args:   msgArgsText,
+		Help:   msgHelpComment,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return notifyReply(p.commentTasks(c.BoardID, c.Command, c.Args, c.UserID, c.UserName))
+		},
+	})
+	botCommands.register(command{
+		Name:     "show",
+		WithID:   true,
+		Help:     msgHelpShow,
+		ReadOnly: true,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return textReply(p.showTask(c.BoardID, c.Command, c.UserID))
+		},
+	})
+	botCommands.register(command{
+		Name:     "log",
+		WithID:   true,
+		Help:     msgHelpLog,
+		ReadOnly: true,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return textReply(p.taskLog(c.BoardID, c.Command, c.UserID))
+		},
+	})
+	botCommands.register(listCommand(viewMy, msgHelpMy))
+	botCommands.register(listCommand(viewOwner, msgHelpOwner))
+	botCommands.register(command{
+		Name:     "done",
+		Help:     msgHelpDone,
+		ReadOnly: true,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return textReply(p.getDoneTasks(c.BoardID, c.UserID))
+		},
+	})
+	botCommands.register(command{
+		Name:     "find",
+		Args:     msgArgsFind,
+		Help:     msgHelpFind,
+		ReadOnly: true,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return textReply(p.findTasks(c.BoardID, c.UserID, c.UserName, c.Args))
+		},
+	})
+	botCommands.register(command{
+		Name:     "boards",
+		Help:     msgHelpBoards,
+		ReadOnly: true,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return textReply(p.getBoards(c.UserID))
+		},
+	})
+	botCommands.register(command{
+		Name:     "role",
+		Args:     msgArgsRole,
+		Help:     msgHelpRole,
+		ReadOnly: true,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return notifyReply(p.roleTasks(c.BoardID, c.Args, c.UserID, c.UserName))
+		},
+	})
+	botCommands.register(command{
+		Name: "admin",
+		Args: msgArgsMention,
+		Help: msgHelpAdmin,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return notifyReply(p.adminTasks(c.BoardID, c.Args, c.UserID, c.UserName))
+		},
+	})
+	botCommands.register(command{
+		Name:   "delete",
+		WithID: true,
+		Help:   msgHelpDelete,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return notifyReply(p.deleteTasks(c.BoardID, c.Command, c.UserID, c.UserName))
+		},
+	})
+	botCommands.register(command{
+		Name:     "lang",
+		Args:     msgArgsLang,
+		Help:     msgHelpLang,
+		ReadOnly: true,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return textReply(p.setLang(c.Args, c.UserID, c.UserName))
+		},
+	})
+	botCommands.register(command{
+		Name:     "digest",
+		Args:     msgArgsDigest,
+		Help:     msgHelpDigest,
+		ReadOnly: true,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			return textReply(p.digestTasks(c.Args, c.UserID, c.UserName))
+		},
+	})
+}
+
+// listCommand - /tasks, /my и /owner: постраничный список с фильтром по тегу
+func listCommand(view, help string) command {
+	return command{
+		Name:     view,
+		Args:     msgArgsList,
+		Help:     help,
+		ReadOnly: true,
+		Handle: func(p *telegramPresenter, c commandContext) commandReply {
+			view, page := parseListArgs(view, c.Args)
+			text, keyboard := p.showTasks(view, page, c.BoardID, c.UserID)
+			return commandReply{Text: text, Keyboard: keyboard}
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	msgCommentUsage = "comment_usage"
+	msgCommentAdded = "comment_added"
+	msgCommentBy    = "comment_by"
+	msgNoComments   = "no_comments"
+	msgComments     = "comments"
+)
+
+type Comment struct {
+	Author *User
+	At     time.Time
+	Text   string
+}
+
+// CommentTask добавляет к задаче комментарий от actor
+func (tm *TaskManager) CommentTask(boardID, taskID int64, actor *User, text string) (CommentAdded, error) {
+	return change(tm, func() (CommentAdded, error) {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return CommentAdded{}, ErrEmptyComment
+		}
+
+		task, err := tm.task(boardID, taskID)
+		if err != nil {
+			return CommentAdded{}, err
+		}
+
+		comment := Comment{
+			Author: &User{
+				ID:       actor.ID,
+				UserName: actor.UserName,
+			},
+			At:   tm.clock.Now(),
+			Text: text,
+		}
+		task.Comments = append(task.Comments, comment)
+		if err := tm.saveTask(task); err != nil {
+			return CommentAdded{}, err
+		}
+
+		return CommentAdded{Task: *task, Comment: comment}, nil
+	})
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestComments(t *testing.T) {
+	tds, bot := newTestBot(t)
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	clock := newFakeClock(time.Date(2026, 10, 17, 12, 0, 0, 0, time.Local))
+	manager.clock = clock
+
+	cases := []testCase{
+		{Ivanov, "/new написать бота", map[int64]string{
+			Ivanov: `Задача "написать бота" создана, id=1`,
+		}},
+		{Ivanov, "/show_1", map[int64]string{
+			Ivanov: "1. написать бота by @ivanov\n/assign_1\n\nкомментариев нет, /comment_1 текст",
+		}},
+		// автор комментирует свою задачу без исполнителя - уведомлять некого
+		{Ivanov, "/comment_1 нужен вебхук", map[int64]string{
+			Ivanov: `Комментарий к задаче "написать бота" добавлен`,
+		}},
+		{Petrov, "/assign_1", map[int64]string{
+			Petrov: `Задача "написать бота" назначена на вас`,
+			Ivanov: `Задача "написать бота" назначена на @ppetrov`,
+		}},
+		// посторонний комментарий получают и автор, и исполнитель
+		{Alexandrov, "/comment_1 могу помочь", map[int64]string{
+			Alexandrov: `Комментарий к задаче "написать бота" добавлен`,
+			Ivanov:     "@aalexandrov к задаче \"написать бота\":\nмогу помочь",
+			Petrov:     "@aalexandrov к задаче \"написать бота\":\nмогу помочь",
+		}},
+		{Petrov, "/comment_1", map[int64]string{
+			Petrov: ru(msgCommentUsage),
+		}},
+		{Petrov, "/comment_2 а где задача?", map[int64]string{
+			Petrov: ru(msgLogNoTasks),
+		}},
+		{Petrov, "/show_1", map[int64]string{
+			Petrov: `1. написать бота by @ivanov
+assignee: я
+/unassign_1 /resolve_1
+
+комментарии:
+@ivanov 17.10.2026 12:00: нужен вебхук
+@aalexandrov 17.10.2026 12:00: могу помочь`,
+		}},
+	}
+
+	for idx, item := range cases {
+		tds.Lock()
+		tds.Answers = make(map[int64]string)
+		tds.Unlock()
+
+		handleUpdate(bot, manager, messageUpdate(item.user, item.command))
+
+		tds.Lock()
+		if !reflect.DeepEqual(tds.Answers, item.answers) {
+			t.Fatalf("[case%d, %d: %s] bad results:\n\tWant: %v\n\tHave: %v", idx, item.user, item.command, item.answers, tds.Answers)
+		}
+		tds.Unlock()
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// Ошибки доменного API TaskManager. Фронтенд сам решает, как показать их пользователю
+var (
+	ErrTaskNotFound    = errors.New("task not found")
+	ErrNotAssignee     = errors.New("task is not assigned to the user")
+	ErrForbidden       = errors.New("not enough permissions")
+	ErrNoAssignees     = errors.New("task has no assignees")
+	ErrNotResolved     = errors.New("task is not resolved")
+	ErrAlreadyAssignee = errors.New("user is already an assignee")
+	ErrAlreadyWatcher  = errors.New("user is already a watcher")
+	ErrNotWatcher      = errors.New("user is not a watcher")
+	ErrEmptyComment    = errors.New("empty comment")
+	ErrNoTags          = errors.New("no tags")
+	ErrBadTag          = errors.New("bad tag")
+	ErrLastAdmin       = errors.New("board must keep at least one admin")
+	ErrUnknownLanguage = errors.New("unknown language")
+	ErrBadDigestTime   = errors.New("bad digest time")
+	ErrStorage         = errors.New("storage error")
+)
+
+// UnknownUserError - пользователь с таким логином ни разу не писал боту
+type UnknownUserError struct {
+	UserName string
+}
+
+func (e *UnknownUserError) Error() string {
+	return fmt.Sprintf("unknown user @%s", e.UserName)
+}
+
+// NotMemberError - пользователь не работал с этой доской, назначать на него ее задачи нельзя
+type NotMemberError struct {
+	UserName string
+}
+
+func (e *NotMemberError) Error() string {
+	return fmt.Sprintf("user @%s is not a board member", e.UserName)
+}
+
+// Event - то, что произошло на доске. Методы TaskManager возвращают событие
+// как результат и рассылают его подписчикам, а кого и как уведомить, решает фронтенд.
+// Задачи в событиях - копии, их можно читать без блокировки
+type Event interface {
+	// Kind - имя события: task_created, task_assigned, ...
+	Kind() string
+}
+
+type TaskCreated struct {
+	Task Task
+}
+
+// TaskAssigned - у задачи новый единственный исполнитель Task.Assignees[0]
+type TaskAssigned struct {
+	Task     Task
+	By       *User
+	Previous []*User
+}
+
+type AssigneeJoined struct {
+	Task Task
+	By   *User
+}
+
+// TaskUnassigned - с задачи сняли исполнителей Removed. Если By среди них,
+// пользователь отказался от задачи сам
+type TaskUnassigned struct {
+	Task    Task
+	By      *User
+	Removed []*User
+}
+
+type WatcherAdded struct {
+	Task Task
+	By   *User
+}
+
+type WatcherRemoved struct {
+	Task Task
+	By   *User
+}
+
+// TaskResolved - задача выполнена, кем и с каким комментарием - в Task.Resolutions
+type TaskResolved struct {
+	Task Task
+	By   *User
+}
+
+type TaskReopened struct {
+	Task Task
+	By   *User
+}
+
+type CommentAdded struct {
+	Task    Task
+	Comment Comment
+}
+
+type TagsChanged struct {
+	Task Task
+	By   *User
+}
+
+type TaskDeleted struct {
+	Task Task
+	By   *User
+}
+
+type RoleChanged struct {
+	BoardID    int64
+	BoardTitle string
+	User       *User
+	Role       BoardRole
+	By         *User
+}
+
+// TaskDueSoon и TaskOverdue - напоминания о сроке, каждое приходит один раз
+type TaskDueSoon struct {
+	Task Task
+}
+
+type TaskOverdue struct {
+	Task Task
+}
+
+func (TaskCreated) Kind() string    { return "task_created" }
+func (TaskAssigned) Kind() string   { return "task_assigned" }
+func (AssigneeJoined) Kind() string { return "assignee_joined" }
+func (TaskUnassigned) Kind() string { return "task_unassigned" }
+func (WatcherAdded) Kind() string   { return "watcher_added" }
+func (WatcherRemoved) Kind() string { return "watcher_removed" }
+func (TaskResolved) Kind() string   { return "task_resolved" }
+func (TaskReopened) Kind() string   { return "task_reopened" }
+func (CommentAdded) Kind() string   { return "comment_added" }
+func (TagsChanged) Kind() string    { return "tags_changed" }
+func (TaskDeleted) Kind() string    { return "task_deleted" }
+func (RoleChanged) Kind() string    { return "role_changed" }
+func (TaskDueSoon) Kind() string    { return "task_due_soon" }
+func (TaskOverdue) Kind() string    { return "task_overdue" }
+
+// Subscribe добавляет обработчик всех событий. Обработчики вызываются
+// без блокировки TaskManager, в той горутине, где произошло событие
+func (tm *TaskManager) Subscribe(handler func(Event)) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tm.subscribers = append(tm.subscribers, handler)
+}
+
+// publish рассылает событие подписчикам, вызывается после tm.mu.Unlock
+func (tm *TaskManager) publish(event Event) {
+	tm.mu.Lock()
+	subscribers := tm.subscribers
+	tm.mu.Unlock()
+
+	for _, handler := range subscribers {
+		handler(event)
+	}
+}
+
+// eventTask - задача, которой касается событие. У событий досок (RoleChanged) ее нет
+func eventTask(event Event) (Task, bool) {
+	switch event := event.(type) {
+	case TaskCreated:
+		return event.Task, true
+	case TaskAssigned:
+		return event.Task, true
+	case AssigneeJoined:
+		return event.Task, true
+	case TaskUnassigned:
+		return event.Task, true
+	case WatcherAdded:
+		return event.Task, true
+	case WatcherRemoved:
+		return event.Task, true
+	case TaskResolved:
+		return event.Task, true
+	case TaskReopened:
+		return event.Task, true
+	case CommentAdded:
+		return event.Task, true
+	case TagsChanged:
+		return event.Task, true
+	case TaskDeleted:
+		return event.Task, true
+	case TaskDueSoon:
+		return event.Task, true
+	case TaskOverdue:
+		return event.Task, true
+	}
+	return Task{}, false
+}
+
+// eventActor - кто сделал изменение, у напоминаний о сроках автора нет
+func eventActor(event Event) *User {
+	switch event := event.(type) {
+	case TaskCreated:
+		return event.Task.Owner
+	case TaskAssigned:
+		return event.By
+	case AssigneeJoined:
+		return event.By
+	case TaskUnassigned:
+		return event.By
+	case WatcherAdded:
+		return event.By
+	case WatcherRemoved:
+		return event.By
+	case TaskResolved:
+		return event.By
+	case TaskReopened:
+		return event.By
+	case CommentAdded:
+		return event.Comment.Author
+	case TagsChanged:
+		return event.By
+	case TaskDeleted:
+		return event.By
+	case RoleChanged:
+		return event.By
+	}
+	return nil
+}
+
+// saveTask сохраняет задачу, ошибка хранилища логируется и превращается в ErrStorage.
+// Вызывается под tm.mu
+func (tm *TaskManager) saveTask(task *Task) error {
+	if err := tm.storage.Save(task); err != nil {
+		log.Printf("Ошибка сохранения задачи: %v", err)
+		return fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	return nil
+}
+
+// task ищет задачу на доске, задачи с других досок считаются несуществующими.
+// Вызывается под tm.mu
+func (tm *TaskManager) task(boardID, taskID int64) (*Task, error) {
+	task, ok := tm.storage.Get(taskID)
+	if !ok || task.BoardID != boardID {
+		return nil, ErrTaskNotFound
+	}
+	// внутри change запоминаем, какой задача была до изменения, для журнала
+	if _, seen := tm.snapshots[task.ID]; tm.snapshots != nil && !seen {
+		tm.snapshots[task.ID] = *task
+	}
+	return task, nil
+}
+
+// openTask - как task, но выполненные задачи тоже считаются несуществующими
+func (tm *TaskManager) openTask(boardID, taskID int64) (*Task, error) {
+	task, err := tm.task(boardID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.isResolved() {
+		return nil, ErrTaskNotFound
+	}
+	return task, nil
+}
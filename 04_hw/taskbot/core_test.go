@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// ядро работает без телеграма: результаты и ошибки - типизированные
+func TestCoreEvents(t *testing.T) {
+	tm := NewTaskManager(NewMemoryStorage())
+	ivanov := &User{ID: Ivanov, UserName: "ivanov"}
+	petrov := &User{ID: Petrov, UserName: "ppetrov"}
+	tm.touchUser(Petrov, "ppetrov", "")
+	tm.touchBoard(personalBoardID, personalBoardTitle, Petrov)
+
+	var kinds []string
+	tm.Subscribe(func(event Event) {
+		kinds = append(kinds, event.Kind())
+	})
+
+	created, err := tm.CreateTask(personalBoardID, ivanov, newTaskFields{Title: "написать бота"})
+	if err != nil {
+		t.Fatalf("CreateTask error: %s", err)
+	}
+	id := created.Task.ID
+
+	assigned, err := tm.AssignTask(personalBoardID, id, ivanov, "@ppetrov")
+	if err != nil {
+		t.Fatalf("AssignTask error: %s", err)
+	}
+	if have := assigned.Task.Assignees[0].UserName; have != "ppetrov" {
+		t.Fatalf("bad assignee: %s", have)
+	}
+
+	if _, err := tm.AssignTask(personalBoardID, id, ivanov, "@nobody"); !errors.As(err, new(*UnknownUserError)) {
+		t.Fatalf("want UnknownUserError, have %v", err)
+	}
+	if _, err := tm.LeaveTask(personalBoardID, id, ivanov); !errors.Is(err, ErrNotAssignee) {
+		t.Fatalf("want ErrNotAssignee, have %v", err)
+	}
+	if _, err := tm.ReopenTask(personalBoardID, id, ivanov); !errors.Is(err, ErrNotResolved) {
+		t.Fatalf("want ErrNotResolved, have %v", err)
+	}
+
+	resolved, err := tm.ResolveTask(personalBoardID, id, petrov, " готово ")
+	if err != nil {
+		t.Fatalf("ResolveTask error: %s", err)
+	}
+	if resolved.Task.resolution().Note != "готово" {
+		t.Fatalf("bad resolution: %+v", resolved.Task.Resolutions)
+	}
+	if _, err := tm.ResolveTask(personalBoardID, id, petrov, ""); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("want ErrTaskNotFound, have %v", err)
+	}
+	if _, err := tm.Task(-100, id); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("task from another board: %v", err)
+	}
+
+	// подписчик получает только удавшиеся изменения
+	want := []string{"task_created", "task_assigned", "task_resolved"}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("bad events:\n\tWant: %v\n\tHave: %v", want, kinds)
+	}
+}
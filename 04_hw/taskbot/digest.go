@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/skinass/telegram-bot-api/v5"
+)
+
+const (
+	msgDigestUsage      = "digest_usage"
+	msgDigestSet        = "digest_set"
+	msgDigestOff        = "digest_off"
+	msgDigestNone       = "digest_none"
+	msgDigestHeader     = "digest_header"
+	msgDigestAssigned   = "digest_assigned"
+	msgDigestUnassigned = "digest_unassigned"
+	msgDigestOverdue    = "digest_overdue"
+	msgDigestRow        = "digest_row"
+	msgDigestOverdueRow = "digest_overdue_row"
+)
+
+// digestLayout - время сводки в /digest 09:00, по часовому поясу бота,
+// digestDayLayout - день, за который сводка уже отправлена
+const (
+	digestLayout    = "15:04"
+	digestDayLayout = "2006-01-02"
+)
+
+// Digest - утренняя сводка пользователя: задачи на нем, его задачи без исполнителя
+// и просроченные из тех и других
+type Digest struct {
+	User       User
+	Date       time.Time
+	Assigned   []Task
+	Unassigned []Task
+	Overdue    []Task
+}
+
+func (d Digest) empty() bool {
+	return len(d.Assigned) == 0 && len(d.Unassigned) == 0 && len(d.Overdue) == 0
+}
+
+// SetDigest включает ежедневную сводку в at (15:04), пустое at выключает ее.
+// Если сегодня это время уже прошло, первая сводка придет завтра
+func (tm *TaskManager) SetDigest(actor *User, at string) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	user, ok := tm.storage.User(actor.ID)
+	if !ok {
+		user = &User{ID: actor.ID, UserName: actor.UserName}
+	}
+	updated := *user
+	updated.DigestAt = ""
+
+	if at != "" {
+		parsed, err := time.Parse(digestLayout, at)
+		if err != nil {
+			return ErrBadDigestTime
+		}
+		now := tm.clock.Now()
+		updated.DigestAt = parsed.Format(digestLayout)
+		if !now.Before(digestTime(now, parsed)) {
+			updated.DigestSent = now.Format(digestDayLayout)
+		}
+	}
+
+	if err := tm.storage.SaveUser(&updated); err != nil {
+		log.Printf("Ошибка сохранения пользователя: %v", err)
+		return fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	return nil
+}
+
+// DigestAt - во сколько пользователь получает сводку, пустая строка - сводка выключена
+func (tm *TaskManager) DigestAt(userID int64) string {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if user, ok := tm.storage.User(userID); ok {
+		return user.DigestAt
+	}
+	return ""
+}
+
+// CollectDigests собирает сводки, время которых сегодня уже наступило, и отмечает их
+// отправленными, так что каждый получает не больше одной сводки в день. Пустые сводки не отправляются
+func (tm *TaskManager) CollectDigests() []Digest {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	now := tm.clock.Now()
+	today := now.Format(digestDayLayout)
+
+	users := tm.storage.Users()
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	var digests []Digest
+	for _, user := range users {
+		if user.DigestAt == "" || user.DigestSent == today {
+			continue
+		}
+		at, err := time.Parse(digestLayout, user.DigestAt)
+		if err != nil || now.Before(digestTime(now, at)) {
+			continue
+		}
+
+		updated := *user
+		updated.DigestSent = today
+		if err := tm.storage.SaveUser(&updated); err != nil {
+			log.Printf("Ошибка сохранения пользователя: %v", err)
+			continue
+		}
+
+		digest := tm.digest(updated, now)
+		if !digest.empty() {
+			digests = append(digests, digest)
+		}
+	}
+	return digests
+}
+
+// digest собирает сводку пользователя со всех досок. Вызывается под tm.mu
+func (tm *TaskManager) digest(user User, now time.Time) Digest {
+	digest := Digest{User: user, Date: now}
+	for _, task := range tm.getOpenTasks() {
+		assigned := task.isAssignee(user.ID)
+		unassigned := task.Owner.ID == user.ID && len(task.Assignees) == 0
+		switch {
+		case !assigned && !unassigned:
+			continue
+		case task.Due != nil && !now.Before(task.deadline()):
+			digest.Overdue = append(digest.Overdue, *task)
+		case assigned:
+			digest.Assigned = append(digest.Assigned, *task)
+		default:
+			digest.Unassigned = append(digest.Unassigned, *task)
+		}
+	}
+	return digest
+}
+
+// digestTime - когда сегодня отправлять сводку, заданную временем at
+func digestTime(now, at time.Time) time.Time {
+	year, month, day := now.Date()
+	return time.Date(year, month, day, at.Hour(), at.Minute(), 0, 0, now.Location())
+}
+
+// digestTasks обрабатывает /digest: без аргументов показывает настройку,
+// /digest 09:00 включает сводку, /digest off выключает
+func (p *telegramPresenter) digestTasks(args string, userID int64, userName string) string {
+	lang := p.languageOf(userID)
+
+	value := strings.TrimSpace(args)
+	switch strings.ToLower(value) {
+	case "":
+		if at := p.DigestAt(userID); at != "" {
+			return tr(lang, msgDigestSet, at)
+		}
+		return tr(lang, msgDigestNone)
+	case "off":
+		if err := p.SetDigest(&User{ID: userID, UserName: userName}, ""); err != nil {
+			return errorText(lang, err)
+		}
+		return tr(lang, msgDigestOff)
+	}
+
+	if err := p.SetDigest(&User{ID: userID, UserName: userName}, value); err != nil {
+		return errorText(lang, err)
+	}
+	return tr(lang, msgDigestSet, p.DigestAt(userID))
+}
+
+// digests - сводки, которые пора отправить, каждая на языке получателя
+func (p *telegramPresenter) digests() []notification {
+	var notifications []notification
+	for _, digest := range p.CollectDigests() {
+		lang := p.languageOf(digest.User.ID)
+
+		text := tr(lang, msgDigestHeader, digest.Date.Format(dueLayout))
+		if len(digest.Assigned) > 0 {
+			text += tr(lang, msgDigestAssigned)
+			for _, task := range digest.Assigned {
+				text += tr(lang, msgDigestRow, task.ID, task.Title)
+			}
+		}
+		if len(digest.Unassigned) > 0 {
+			text += tr(lang, msgDigestUnassigned)
+			for _, task := range digest.Unassigned {
+				text += tr(lang, msgDigestRow, task.ID, task.Title)
+			}
+		}
+		if len(digest.Overdue) > 0 {
+			text += tr(lang, msgDigestOverdue)
+			for _, task := range digest.Overdue {
+				text += tr(lang, msgDigestOverdueRow, task.ID, task.Title, task.Due.Format(dueLayout))
+			}
+		}
+
+		notifications = append(notifications, notification{ChatID: digest.User.ID, Text: text})
+	}
+	return notifications
+}
+
+// runDigests раз в every проверяет, кому пора отправить сводку, пока не отменят ctx
+func runDigests(ctx context.Context, bot Sender, p *telegramPresenter, every time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.clock.After(every):
+		}
+
+		for _, n := range p.digests() {
+			for _, chunk := range splitMessage(n.Text, maxMessageLength) {
+				if _, err := bot.Send(tgbotapi.NewMessage(n.ChatID, chunk)); err != nil {
+					log.Printf("Ошибка отправки сводки: %v", err)
+					metrics.sendFailed(sendDigest)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDigests(t *testing.T) {
+	tds, bot := newTestBot(t)
+
+	clock := newFakeClock(time.Date(2026, 10, 17, 8, 0, 0, 0, time.Local))
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.clock = clock
+	manager.touchUser(Ivanov, "ivanov", "")
+	manager.touchUser(Petrov, "ppetrov", "")
+	manager.touchBoard(personalBoardID, personalBoardTitle, Ivanov)
+
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new написать бота due:2026-10-17")
+	manager.addTasks(personalBoardID, Petrov, "ppetrov", "/new сделать ДЗ")
+	manager.delegateTasks(personalBoardID, "assign_2", "@ivanov", Petrov, "ppetrov")
+	manager.addTasks(personalBoardID, Petrov, "ppetrov", "/new чужая задача")
+
+	if have := manager.digestTasks("25:00", Ivanov, "ivanov"); have != ru(msgDigestUsage) {
+		t.Fatalf("bad time is accepted: %s", have)
+	}
+	if have := manager.digestTasks("", Ivanov, "ivanov"); have != ru(msgDigestNone) {
+		t.Fatalf("digest is on by default: %s", have)
+	}
+	if have := manager.digestTasks("9:00", Ivanov, "ivanov"); have != ru(msgDigestSet, "09:00") {
+		t.Fatalf("bad /digest reply: %s", have)
+	}
+	// настройка не теряется, когда пользователь снова пишет боту
+	manager.touchUser(Ivanov, "ivanov", "ru")
+	if have := manager.digestTasks("", Ivanov, "ivanov"); have != ru(msgDigestSet, "09:00") {
+		t.Fatalf("digest setting is lost: %s", have)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runDigests(ctx, bot, manager, 30*time.Minute)
+
+	clock.WaitWaiters(t)
+	step := func(d time.Duration) {
+		clock.Advance(d)
+		clock.WaitWaiters(t)
+	}
+
+	step(30 * time.Minute)
+	waitAnswers(t, tds, map[int64]string{})
+
+	// 17.10 09:00 - у Петрова сводка не включена, Иванову отправляем
+	step(30 * time.Minute)
+	waitAnswers(t, tds, map[int64]string{
+		Ivanov: "Сводка задач на 17.10.2026:\n\nНа вас:\n2. сделать ДЗ\n\nВаши задачи без исполнителя:\n1. написать бота",
+	})
+
+	// второй раз за день не отправляем
+	step(30 * time.Minute)
+	waitAnswers(t, tds, map[int64]string{})
+
+	// 18.10 09:30 - первая задача уже просрочена
+	step(24 * time.Hour)
+	waitAnswers(t, tds, map[int64]string{
+		Ivanov: "Сводка задач на 18.10.2026:\n\nНа вас:\n2. сделать ДЗ\n\nПросрочено:\n1. написать бота, срок был 17.10.2026",
+	})
+
+	if have := manager.digestTasks("off", Ivanov, "ivanov"); have != ru(msgDigestOff) {
+		t.Fatalf("bad /digest off reply: %s", have)
+	}
+	step(24 * time.Hour)
+	waitAnswers(t, tds, map[int64]string{})
+}
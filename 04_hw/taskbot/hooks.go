@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// hookQueueSize - сколько событий ждет отправки в одну подписку, остальные теряются
+	hookQueueSize = 256
+	// hookLogSize - сколько последних попыток доставки хранится в журнале
+	hookLogSize = 100
+	// hookMaxBackoff - дольше этого между попытками не ждем
+	hookMaxBackoff = 5 * time.Minute
+	// hookDrainTimeout - сколько после остановки бота дожидаемся отправки очередей,
+	// потом незавершенные запросы прерываются, а остальные события теряются
+	hookDrainTimeout = 10 * time.Second
+
+	hookSignatureHeader = "X-Taskbot-Signature"
+	hookEventHeader     = "X-Taskbot-Event"
+	hookDeliveryHeader  = "X-Taskbot-Delivery"
+)
+
+// hookSubscription - внешний адрес, куда POST-ом уходят события задач.
+// Events - имена событий (task_created, task_assigned, ...), пустой список - все события.
+// Тело подписывается HMAC-SHA256 с Secret: X-Taskbot-Signature: sha256=$HEX
+type hookSubscription struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events,omitempty"`
+}
+
+func (s hookSubscription) wants(kind string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, event := range s.Events {
+		if event == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// loadHookSubscriptions читает подписки из JSON-файла -tg.hooks
+func loadHookSubscriptions(path string) ([]hookSubscription, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read hooks failed: %w", err)
+	}
+
+	var subscriptions []hookSubscription
+	if err := json.Unmarshal(data, &subscriptions); err != nil {
+		return nil, fmt.Errorf("parse hooks failed: %w", err)
+	}
+	for _, s := range subscriptions {
+		if s.URL == "" {
+			return nil, fmt.Errorf("hook without url")
+		}
+		// без секрета получатель не может проверить, что событие пришло от бота
+		if s.Secret == "" {
+			return nil, fmt.Errorf("hook %s without secret", s.URL)
+		}
+	}
+	return subscriptions, nil
+}
+
+// hookPayload - тело запроса, задача - в той же схеме, что и в REST API
+type hookPayload struct {
+	Delivery int64     `json:"delivery"`
+	Event    string    `json:"event"`
+	At       time.Time `json:"at"`
+	Actor    *apiUser  `json:"actor,omitempty"`
+	Task     apiTask   `json:"task"`
+}
+
+// hookAttempt - запись журнала доставки, по одной на каждую попытку
+type hookAttempt struct {
+	Delivery int64     `json:"delivery"`
+	URL      string    `json:"url"`
+	Event    string    `json:"event"`
+	Attempt  int       `json:"attempt"`
+	At       time.Time `json:"at"`
+	Status   int       `json:"status,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	OK       bool      `json:"ok"`
+}
+
+type hookDelivery struct {
+	payload hookPayload
+	body    []byte
+}
+
+type hookTarget struct {
+	subscription hookSubscription
+	queue        chan hookDelivery
+}
+
+// hookDispatcher подписывается на события TaskManager и доставляет их по подпискам.
+// У каждой подписки своя очередь и горутина, так что медленный адрес не задерживает остальные,
+// а события в одну подписку приходят по порядку
+type hookDispatcher struct {
+	clock    Clock
+	client   *http.Client
+	attempts int
+	backoff  time.Duration
+	drain    time.Duration
+	targets  []hookTarget
+	wg       sync.WaitGroup
+	// stopSend прерывает запросы, которые не успели за drain после остановки
+	stopSend context.CancelFunc
+
+	mu     sync.Mutex
+	closed bool
+	nextID int64
+	log    []hookAttempt
+}
+
+func newHookDispatcher(clock Clock, subscriptions []hookSubscription, attempts int, backoff time.Duration) *hookDispatcher {
+	d := &hookDispatcher{
+		clock:    clock,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		attempts: attempts,
+		backoff:  backoff,
+		drain:    hookDrainTimeout,
+	}
+	if d.attempts < 1 {
+		d.attempts = 1
+	}
+	for _, s := range subscriptions {
+		d.targets = append(d.targets, hookTarget{
+			subscription: s,
+			queue:        make(chan hookDelivery, hookQueueSize),
+		})
+	}
+	return d
+}
+
+// start запускает доставку. После отмены ctx повторные попытки прекращаются,
+// а то, что уже в очереди, отправляется по одному разу, пока не пройдет d.drain
+func (d *hookDispatcher) start(ctx context.Context) {
+	sendCtx, stopSend := context.WithCancel(context.Background())
+	d.stopSend = stopSend
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-sendCtx.Done():
+			return
+		}
+		select {
+		case <-d.clock.After(d.drain):
+			stopSend()
+		case <-sendCtx.Done():
+		}
+	}()
+
+	for _, target := range d.targets {
+		d.wg.Add(1)
+		go func(target hookTarget) {
+			defer d.wg.Done()
+			for delivery := range target.queue {
+				if sendCtx.Err() != nil {
+					d.drop(target.subscription, delivery.payload, "shutdown")
+					continue
+				}
+				d.deliver(ctx, sendCtx, target.subscription, delivery)
+			}
+		}(target)
+	}
+}
+
+// close перестает принимать события и ждет, пока очереди опустеют.
+// Если бот уже остановлен, ждет не дольше d.drain
+func (d *hookDispatcher) close() {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		for _, target := range d.targets {
+			close(target.queue)
+		}
+	}
+	d.mu.Unlock()
+
+	d.wg.Wait()
+	if d.stopSend != nil {
+		d.stopSend()
+	}
+}
+
+// handle - подписчик TaskManager, ставит событие в очереди подписок и не ждет доставки
+func (d *hookDispatcher) handle(event Event) {
+	task, ok := eventTask(event)
+	if !ok {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return
+	}
+	for _, target := range d.targets {
+		if !target.subscription.wants(event.Kind()) {
+			continue
+		}
+
+		d.nextID++
+		payload := hookPayload{
+			Delivery: d.nextID,
+			Event:    event.Kind(),
+			At:       d.clock.Now(),
+			Task:     newAPITask(task),
+		}
+		if actor := eventActor(event); actor != nil {
+			user := newAPIUser(actor)
+			payload.Actor = &user
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			log.Printf("Ошибка сериализации события: %v", err)
+			continue
+		}
+
+		select {
+		case target.queue <- hookDelivery{payload: payload, body: body}:
+		default:
+			log.Printf("Очередь вебхука %s переполнена, событие %d потеряно", target.subscription.URL, payload.Delivery)
+			d.appendLog(hookAttempt{
+				Delivery: payload.Delivery,
+				URL:      target.subscription.URL,
+				Event:    payload.Event,
+				At:       payload.At,
+				Error:    "queue is full",
+			})
+		}
+	}
+}
+
+// drop записывает в журнал событие, которое так и не отправили
+func (d *hookDispatcher) drop(s hookSubscription, payload hookPayload, reason string) {
+	log.Printf("Событие %d для вебхука %s не отправлено: %s", payload.Delivery, s.URL, reason)
+	d.record(hookAttempt{
+		Delivery: payload.Delivery,
+		URL:      s.URL,
+		Event:    payload.Event,
+		At:       d.clock.Now(),
+		Error:    reason,
+	})
+}
+
+// deliver отправляет событие, пока адрес не ответит 2xx или не кончатся попытки.
+// Между попытками ждем backoff, 2*backoff, 4*backoff, ... Запросы прерываются с отменой sendCtx
+func (d *hookDispatcher) deliver(ctx, sendCtx context.Context, s hookSubscription, delivery hookDelivery) {
+	for attempt := 1; ; attempt++ {
+		status, err := d.post(sendCtx, s, delivery)
+		result := hookAttempt{
+			Delivery: delivery.payload.Delivery,
+			URL:      s.URL,
+			Event:    delivery.payload.Event,
+			Attempt:  attempt,
+			At:       d.clock.Now(),
+			Status:   status,
+			OK:       err == nil,
+		}
+		if err != nil {
+			log.Printf("Ошибка доставки вебхука %s, попытка %d: %v", s.URL, attempt, err)
+			result.Error = err.Error()
+		}
+		d.record(result)
+
+		if err == nil || attempt >= d.attempts {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.clock.After(hookBackoff(d.backoff, attempt)):
+		}
+	}
+}
+
+func (d *hookDispatcher) post(ctx context.Context, s hookSubscription, delivery hookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(delivery.body))
+	if err != nil {
+		return 0, fmt.Errorf("new request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(hookEventHeader, delivery.payload.Event)
+	req.Header.Set(hookDeliveryHeader, fmt.Sprint(delivery.payload.Delivery))
+	req.Header.Set(hookSignatureHeader, signHook(s.Secret, delivery.body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("post failed: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("bad status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *hookDispatcher) record(attempt hookAttempt) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.appendLog(attempt)
+}
+
+// appendLog вызывается под d.mu
+func (d *hookDispatcher) appendLog(attempt hookAttempt) {
+	d.log = append(d.log, attempt)
+	if len(d.log) > hookLogSize {
+		d.log = d.log[len(d.log)-hookLogSize:]
+	}
+}
+
+// deliveries - журнал последних попыток доставки, от старых к новым
+func (d *hookDispatcher) deliveries() []hookAttempt {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]hookAttempt(nil), d.log...)
+}
+
+func hookBackoff(base time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < hookMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > hookMaxBackoff {
+		return hookMaxBackoff
+	}
+	return delay
+}
+
+// signHook - подпись тела для получателя: sha256=HEX(HMAC-SHA256(secret, body))
+func signHook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHookBackoff(t *testing.T) {
+	for attempt, want := range map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		3:  4 * time.Second,
+		30: hookMaxBackoff,
+	} {
+		if have := hookBackoff(time.Second, attempt); have != want {
+			t.Fatalf("attempt %d: want %s, have %s", attempt, want, have)
+		}
+	}
+}
+
+func TestHooksDelivery(t *testing.T) {
+	const secret = "s3cret"
+
+	var (
+		mu       sync.Mutex
+		requests int
+		payloads []hookPayload
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if have := r.Header.Get(hookSignatureHeader); have != signHook(secret, body) {
+			t.Errorf("bad signature %q", have)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		// первые две попытки получатель недоступен
+		if requests <= 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var payload hookPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("bad payload: %s", err)
+		}
+		payloads = append(payloads, payload)
+	}))
+	defer receiver.Close()
+
+	tm := NewTaskManager(NewMemoryStorage())
+	hooks := newHookDispatcher(realClock{}, []hookSubscription{{
+		URL:    receiver.URL,
+		Secret: secret,
+		Events: []string{"task_created", "task_resolved"},
+	}}, 5, time.Millisecond)
+	tm.Subscribe(hooks.handle)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hooks.start(ctx)
+
+	ivanov := &User{ID: Ivanov, UserName: "ivanov"}
+	created, _ := tm.CreateTask(personalBoardID, ivanov, newTaskFields{Title: "написать бота"})
+	tm.AssignTask(personalBoardID, created.Task.ID, ivanov, "")
+	tm.ResolveTask(personalBoardID, created.Task.ID, ivanov, "")
+
+	hooks.close()
+	// после остановки события просто не отправляются
+	tm.ReopenTask(personalBoardID, created.Task.ID, ivanov)
+
+	if len(payloads) != 2 {
+		t.Fatalf("want 2 payloads, have %+v", payloads)
+	}
+	if payloads[0].Event != "task_created" || payloads[1].Event != "task_resolved" {
+		t.Fatalf("bad events order: %+v", payloads)
+	}
+	resolved := payloads[1]
+	if resolved.Actor == nil || resolved.Actor.UserName != "ivanov" || resolved.Task.Resolution == nil {
+		t.Fatalf("bad payload: %+v", resolved)
+	}
+
+	var have []string
+	for _, attempt := range hooks.deliveries() {
+		have = append(have, attempt.Event+" "+http.StatusText(attempt.Status))
+	}
+	want := []string{
+		"task_created Internal Server Error",
+		"task_created Internal Server Error",
+		"task_created OK",
+		"task_resolved OK",
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("bad delivery log:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+}
+
+func TestLoadHookSubscriptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hooks.json")
+	for data, valid := range map[string]bool{
+		`[{"url": "http://localhost/hook", "secret": "s3cret"}]`: true,
+		`[{"url": "http://localhost/hook"}]`:                     false,
+		`[{"url": "http://localhost/hook", "secret": ""}]`:       false,
+		`[{"secret": "s3cret"}]`:                                 false,
+	} {
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatalf("WriteFile error: %s", err)
+		}
+		if _, err := loadHookSubscriptions(path); (err == nil) != valid {
+			t.Errorf("%s: want valid %v, have error %v", data, valid, err)
+		}
+	}
+}
+
+// TestHooksDrainDeadline - после остановки бота зависший получатель не задерживает ее дольше drain
+func TestHooksDrainDeadline(t *testing.T) {
+	started := make(chan struct{}, hookQueueSize)
+	release := make(chan struct{})
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer receiver.Close()
+	defer close(release)
+
+	clock := newFakeClock(time.Date(2026, 10, 17, 12, 0, 0, 0, time.Local))
+	tm := NewTaskManager(NewMemoryStorage())
+	hooks := newHookDispatcher(clock, []hookSubscription{{URL: receiver.URL, Secret: "s3cret"}}, 3, time.Second)
+	tm.Subscribe(hooks.handle)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hooks.start(ctx)
+
+	ivanov := &User{ID: Ivanov, UserName: "ivanov"}
+	for _, title := range []string{"первая", "вторая", "третья"} {
+		tm.CreateTask(personalBoardID, ivanov, newTaskFields{Title: title})
+	}
+	<-started
+
+	cancel()
+	clock.WaitWaiters(t)
+	closed := make(chan struct{})
+	go func() {
+		hooks.close()
+		close(closed)
+	}()
+	clock.Advance(hookDrainTimeout)
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("close waits for the hung receiver after the drain deadline")
+	}
+
+	var have []string
+	for _, attempt := range hooks.deliveries() {
+		if attempt.OK {
+			t.Fatalf("delivery to the hung receiver succeeded: %+v", attempt)
+		}
+		have = append(have, fmt.Sprint(attempt.Delivery, " ", attempt.Error == "shutdown"))
+	}
+	want := []string{"1 false", "2 true", "3 true"}
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("bad delivery log:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+}
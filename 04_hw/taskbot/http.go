@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	tgbotapi "github.com/skinass/telegram-bot-api/v5"
+)
+
+// httpAddr - адрес HTTP-сервера бота, порт берется из $PORT
+func httpAddr() string {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8081"
+	}
+	return ":" + port
+}
+
+// botStatus - состояние бота для /readyz
+type botStatus struct {
+	// receiving - вебхук зарегистрирован или идет polling
+	receiving atomic.Bool
+}
+
+// newHTTPServer собирает свой mux, а не пользуется http.DefaultServeMux,
+// чтобы бота можно было запускать и останавливать несколько раз в одном процессе.
+// api и webhook могут быть nil, тогда REST API выключен, а апдейты через HTTP не принимаются
+func newHTTPServer(addr string, manager *TaskManager, status *botStatus, api, webhook http.Handler) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/state", func(w http.ResponseWriter, r *http.Request) {
+		writeText(w, http.StatusOK, "all is working")
+	})
+	// /healthz - процесс жив и отвечает
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeText(w, http.StatusOK, "ok")
+	})
+	// /readyz - бот получает апдейты и может сохранять задачи
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !status.receiving.Load() {
+			writeText(w, http.StatusServiceUnavailable, "updates are not received")
+			return
+		}
+		if err := manager.pingStorage(); err != nil {
+			log.Printf("Хранилище недоступно: %v", err)
+			writeText(w, http.StatusServiceUnavailable, "storage is not available")
+			return
+		}
+		writeText(w, http.StatusOK, "ok")
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := metrics.write(w, manager.openTasksByBoard()); err != nil {
+			log.Printf("Ошибка записи метрик: %v", err)
+		}
+	})
+	if api != nil {
+		mux.Handle(apiPrefix, api)
+	}
+	if webhook != nil {
+		mux.Handle("/", webhook)
+	}
+
+	return &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+}
+
+func writeText(w http.ResponseWriter, status int, text string) {
+	w.WriteHeader(status)
+	if _, err := w.Write([]byte(text)); err != nil {
+		log.Printf("Ошибка записи в ResponseWriter: %v", err)
+	}
+}
+
+func (tm *TaskManager) pingStorage() error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	return tm.storage.Ping()
+}
+
+// webhookReceiver принимает апдейты от телеграма и складывает их в канал.
+// После close новые апдейты не принимаются (телеграм пришлет их повторно),
+// а канал закрывается, когда уже принятые запросы дописали в него свои апдейты
+type webhookReceiver struct {
+	mu      sync.RWMutex
+	closed  bool
+	updates chan tgbotapi.Update
+}
+
+func newWebhookReceiver(buffer int) *webhookReceiver {
+	return &webhookReceiver{
+		updates: make(chan tgbotapi.Update, buffer),
+	}
+}
+
+func (wr *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "wrong HTTP method required POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var update tgbotapi.Update
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		log.Printf("Ошибка разбора апдейта: %v", err)
+		http.Error(w, "bad update", http.StatusBadRequest)
+		return
+	}
+
+	wr.mu.RLock()
+	defer wr.mu.RUnlock()
+
+	if wr.closed {
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	wr.updates <- update
+}
+
+func (wr *webhookReceiver) close() {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	if !wr.closed {
+		wr.closed = true
+		close(wr.updates)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+const (
+	langRu = "ru"
+	langEn = "en"
+
+	defaultLang = langRu
+)
+
+const (
+	msgLangUsage = "lang_usage"
+	msgLangSet   = "lang_set"
+)
+
+// catalogs - шаблоны сообщений по языкам. Ключ - id сообщения (константы msg*),
+// значение - формат для fmt.Sprintf
+var catalogs = map[string]map[string]string{
+	langRu: catalogRu,
+	langEn: catalogEn,
+}
+
+// tr рендерит сообщение на нужном языке. Если перевода нет,
+// используется русский текст
+func tr(lang, id string, args ...any) string {
+	format, ok := catalogs[lang][id]
+	if !ok {
+		format, ok = catalogs[defaultLang][id]
+	}
+	if !ok {
+		log.Printf("Нет текста для сообщения %q", id)
+		return id
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// phrase - сообщение, которое рендерится на языке получателя
+type phrase func(lang string) string
+
+func say(id string, args ...any) phrase {
+	return func(lang string) string {
+		return tr(lang, id, args...)
+	}
+}
+
+// supportedLang приводит language_code из телеграма (en-US, ru) к языку бота
+func supportedLang(code string) (string, bool) {
+	lang, _, _ := strings.Cut(strings.ToLower(code), "-")
+	if _, ok := catalogs[lang]; !ok {
+		return "", false
+	}
+	return lang, true
+}
+
+// userLang - язык пользователя: выбранный через /lang, иначе язык
+// его телеграм-клиента, иначе русский
+func (tm *TaskManager) userLang(userID int64) string {
+	if user, ok := tm.storage.User(userID); ok {
+		if user.Language != "" {
+			return user.Language
+		}
+		if lang, ok := supportedLang(user.LanguageCode); ok {
+			return lang
+		}
+	}
+	return defaultLang
+}
+
+func (tm *TaskManager) languageOf(userID int64) string {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	return tm.userLang(userID)
+}
+
+// SetLanguage запоминает язык, выбранный пользователем
+func (tm *TaskManager) SetLanguage(actor *User, lang string) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if _, ok := catalogs[lang]; !ok {
+		return ErrUnknownLanguage
+	}
+
+	user, ok := tm.storage.User(actor.ID)
+	if !ok {
+		user = &User{ID: actor.ID, UserName: actor.UserName}
+	}
+	updated := *user
+	updated.Language = lang
+	if err := tm.storage.SaveUser(&updated); err != nil {
+		log.Printf("Ошибка сохранения пользователя: %v", err)
+		return fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+
+	return nil
+}
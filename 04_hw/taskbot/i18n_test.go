@@ -0,0 +1,95 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func ru(id string, args ...any) string {
+	return tr(langRu, id, args...)
+}
+
+func TestCatalogs(t *testing.T) {
+	for id := range catalogRu {
+		if _, ok := catalogEn[id]; !ok {
+			t.Errorf("no english text for %q", id)
+		}
+	}
+	for id := range catalogEn {
+		if _, ok := catalogRu[id]; !ok {
+			t.Errorf("no russian text for %q", id)
+		}
+	}
+
+	if have := tr("de", msgNoTasks); have != "Нет задач" {
+		t.Fatalf("unknown language must fall back to russian, have %q", have)
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	tds, bot := newTestBot(t)
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+
+	// у Петрова телеграм на английском, Иванов и Александров пишут по-русски
+	english := func(userID int64, text string) func() {
+		return func() {
+			update := messageUpdate(userID, text)
+			from := *update.Message.From
+			from.LanguageCode = "en-US"
+			update.Message.From = &from
+			handleUpdate(bot, manager, update)
+		}
+	}
+	russian := func(userID int64, text string) func() {
+		return func() {
+			handleUpdate(bot, manager, messageUpdate(userID, text))
+		}
+	}
+
+	cases := []struct {
+		send    func()
+		answers map[int64]string
+	}{
+		{english(Petrov, "/new write a bot"), map[int64]string{
+			Petrov: `Task "write a bot" created, id=1`,
+		}},
+		{russian(Ivanov, "/assign_1"), map[int64]string{
+			Ivanov: `Задача "write a bot" назначена на вас`,
+			Petrov: `Task "write a bot" is assigned to @ivanov`,
+		}},
+		{english(Petrov, "/my"), map[int64]string{
+			Petrov: "You have no tasks",
+		}},
+		{russian(Ivanov, "/lang en"), map[int64]string{
+			Ivanov: "Language: English",
+		}},
+		// выбранный язык важнее языка телеграм-клиента
+		{russian(Ivanov, "/my"), map[int64]string{
+			Ivanov: "1. write a bot by @ppetrov\n/unassign_1 /resolve_1",
+		}},
+		{english(Petrov, "/lang ru"), map[int64]string{
+			Petrov: "Язык: русский",
+		}},
+		{english(Ivanov, "/resolve_1"), map[int64]string{
+			Ivanov: `Task "write a bot" is resolved`,
+			Petrov: `Задача "write a bot" выполнена @ivanov`,
+		}},
+		{russian(Alexandrov, "/lang de"), map[int64]string{
+			Alexandrov: ru(msgLangUsage),
+		}},
+	}
+
+	for idx, item := range cases {
+		tds.Lock()
+		tds.Answers = make(map[int64]string)
+		tds.Unlock()
+
+		item.send()
+
+		tds.Lock()
+		if !reflect.DeepEqual(tds.Answers, item.answers) {
+			t.Fatalf("[case%d] bad results:\n\tWant: %v\n\tHave: %v", idx, item.answers, tds.Answers)
+		}
+		tds.Unlock()
+	}
+}
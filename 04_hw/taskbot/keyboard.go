@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/skinass/telegram-bot-api/v5"
+)
+
+const (
+	msgButtonAssign   = "button_assign"
+	msgButtonUnassign = "button_unassign"
+	msgButtonResolve  = "button_resolve"
+	msgButtonPrev     = "button_prev"
+	msgButtonNext     = "button_next"
+)
+
+// списки задач, которые можно перерисовать после нажатия кнопки
+const (
+	viewAll   = "tasks"
+	viewOwner = "owner"
+	viewMy    = "my"
+)
+
+// телеграм обрезает всплывающее уведомление после нажатия кнопки до 200 символов
+const maxCallbackTextLength = 200
+
+// taskButtons - кнопки с теми же действиями, что и команды под задачей.
+// В данных кнопки запоминается список, страница и для кого список построен,
+// чтобы перерисовать его таким же.
+func taskButtons(task Task, userID int64, view string, page int, lang string) []tgbotapi.InlineKeyboardButton {
+	switch {
+	case len(task.Assignees) == 0:
+		return []tgbotapi.InlineKeyboardButton{
+			taskButton(tr(lang, msgButtonAssign), "assign", task.ID, view, page, userID),
+		}
+	case task.isAssignee(userID):
+		return []tgbotapi.InlineKeyboardButton{
+			taskButton(tr(lang, msgButtonUnassign), "unassign", task.ID, view, page, userID),
+			taskButton(tr(lang, msgButtonResolve), "resolve", task.ID, view, page, userID),
+		}
+	}
+	return nil
+}
+
+func taskButton(label, action string, taskID int64, view string, page int, userID int64) tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardButtonData(
+		fmt.Sprintf("%s #%d", label, taskID),
+		callbackData(fmt.Sprintf("%s_%d", action, taskID), view, page, userID),
+	)
+}
+
+func inlineKeyboard(rows [][]tgbotapi.InlineKeyboardButton) *tgbotapi.InlineKeyboardMarkup {
+	if len(rows) == 0 {
+		return nil
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return &keyboard
+}
+
+// listCallback - данные кнопки под списком задач
+type listCallback struct {
+	Command string
+	View    string
+	Page    int
+	// UserID - для кого построен список. В группе кнопку может нажать кто угодно,
+	// а список перерисовывается для того, кто его запросил. 0 - кнопка из старого сообщения
+	UserID int64
+}
+
+// parseCallbackData разбирает данные кнопки вида assign_1:tasks:2:1001,
+// у кнопок из старых сообщений последней части нет
+func parseCallbackData(data string) (listCallback, bool) {
+	parts := strings.Split(data, ":")
+	if len(parts) != 3 && len(parts) != 4 {
+		return listCallback{}, false
+	}
+
+	page, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return listCallback{}, false
+	}
+	cb := listCallback{Command: parts[0], View: parts[1], Page: page}
+	if len(parts) == 4 {
+		if cb.UserID, err = strconv.ParseInt(parts[3], 10, 64); err != nil {
+			return listCallback{}, false
+		}
+	}
+
+	base, _, _ := strings.Cut(cb.View, "~")
+	switch base, _ = splitView(base); base {
+	case viewAll, viewOwner, viewMy:
+		return cb, true
+	}
+	return listCallback{}, false
+}
+
+// handleCallback выполняет действие с кнопки, показывает результат
+// всплывающим уведомлением и перерисовывает исходный список задач
+func handleCallback(bot *tgbotapi.BotAPI, p *telegramPresenter, query *tgbotapi.CallbackQuery) {
+	log.Printf("[%s] callback %s", query.From.UserName, query.Data)
+
+	cb, ok := parseCallbackData(query.Data)
+	if !ok || query.Message == nil {
+		answerCallback(bot, query.ID, tr(p.languageOf(query.From.ID), msgUnknownCommand))
+		return
+	}
+
+	boardID, boardTitle := boardForChat(query.Message.Chat)
+	c := commandContext{
+		BoardID:  boardID,
+		UserID:   query.From.ID,
+		UserName: query.From.UserName,
+		Command:  cb.Command,
+	}
+	p.touchBoard(boardID, boardTitle, c.UserID)
+	p.touchUser(c.UserID, c.UserName, query.From.LanguageCode)
+	c.Lang = p.languageOf(c.UserID)
+
+	// кнопка "page" только перелистывает список, остальные - команды с id задачи
+	var reply commandReply
+	if cb.Command != "page" {
+		reply = runCommand(p, c)
+	}
+
+	answerCallback(bot, query.ID, reply.Text)
+
+	listUserID := cb.UserID
+	if listUserID == 0 {
+		listUserID = c.UserID
+	}
+	text, keyboard := p.showTasks(p.callbackView(boardID, cb.View), cb.Page, boardID, listUserID)
+	// отредактировать можно только одно сообщение, поэтому лишнее отрезаем
+	text = splitMessage(text, maxMessageLength)[0]
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, text)
+	edit.ReplyMarkup = keyboard
+	if _, err := bot.Send(edit); err != nil {
+		log.Printf("Ошибка редактирования сообщения: %v", err)
+		metrics.sendFailed(sendEdit)
+	}
+
+	for _, n := range reply.Notifications {
+		notify(bot, n.ChatID, n.Text)
+	}
+}
+
+// callbackView восстанавливает тег списка по ключу из данных кнопки, перебирая теги
+// задач доски. Если задач с таким тегом больше нет, фильтр не совпадет ни с одной
+func (p *telegramPresenter) callbackView(boardID int64, view string) string {
+	base, key, ok := strings.Cut(view, "~")
+	if !ok {
+		return view
+	}
+	for _, task := range p.OpenTasks(boardID) {
+		for _, tag := range task.Tags {
+			if tagKey(tag) == key {
+				return base + "#" + tag
+			}
+		}
+	}
+	return base + "#~" + key
+}
+
+func answerCallback(bot *tgbotapi.BotAPI, queryID, text string) {
+	if _, err := bot.Request(tgbotapi.NewCallback(queryID, truncateText(text, maxCallbackTextLength))); err != nil {
+		log.Printf("Ошибка ответа на нажатие кнопки: %v", err)
+		metrics.sendFailed(sendCallback)
+	}
+}
+
+// truncateText обрезает текст до limit символов (в UTF-16), ставя в конце многоточие
+func truncateText(text string, limit int) string {
+	if messageLength(text) <= limit {
+		return text
+	}
+
+	length := 0
+	for i, r := range text {
+		// одно место оставляем под многоточие
+		if length+utf16Len(r) > limit-1 {
+			return text[:i] + "…"
+		}
+		length += utf16Len(r)
+	}
+	return text
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/skinass/telegram-bot-api/v5"
+)
+
+// messageUpdate собирает апдейт с командой из личного чата, как SendMsgToBot
+func messageUpdate(userID int64, text string) tgbotapi.Update {
+	user := users[userID]
+	return tgbotapi.Update{
+		Message: &tgbotapi.Message{
+			From: user,
+			Chat: &tgbotapi.Chat{ID: user.ID, UserName: user.UserName, Type: "private"},
+			Text: text,
+			Entities: []tgbotapi.MessageEntity{
+				{Type: "bot_command", Offset: 0, Length: len(strings.Fields(text)[0])},
+			},
+		},
+	}
+}
+
+func callbackUpdate(userID int64, queryID, data string) tgbotapi.Update {
+	user := users[userID]
+	return tgbotapi.Update{
+		CallbackQuery: &tgbotapi.CallbackQuery{
+			ID:   queryID,
+			From: user,
+			Message: &tgbotapi.Message{
+				MessageID: 42,
+				Chat:      &tgbotapi.Chat{ID: user.ID, Type: "private"},
+			},
+			Data: data,
+		},
+	}
+}
+
+// keyboardData - callback_data всех кнопок клавиатуры по рядам
+func keyboardData(t *testing.T, raw string) [][]string {
+	t.Helper()
+	if raw == "" {
+		return nil
+	}
+
+	var keyboard tgbotapi.InlineKeyboardMarkup
+	if err := json.Unmarshal([]byte(raw), &keyboard); err != nil {
+		t.Fatalf("bad reply_markup %q: %s", raw, err)
+	}
+
+	var rows [][]string
+	for _, buttons := range keyboard.InlineKeyboard {
+		var row []string
+		for _, button := range buttons {
+			row = append(row, *button.CallbackData)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func TestInlineKeyboard(t *testing.T) {
+	tds, bot := newTestBot(t)
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+
+	handleUpdate(bot, manager, messageUpdate(Ivanov, "/new написать бота"))
+	handleUpdate(bot, manager, messageUpdate(Ivanov, "/new прийти на хакатон"))
+	handleUpdate(bot, manager, messageUpdate(Petrov, "/tasks"))
+
+	tds.Lock()
+	keyboard := keyboardData(t, tds.Keyboards[Petrov])
+	tds.Unlock()
+	want := [][]string{{"assign_1:tasks:1:512"}, {"assign_2:tasks:1:512"}}
+	if !reflect.DeepEqual(keyboard, want) {
+		t.Fatalf("bad /tasks keyboard:\n\tWant: %v\n\tHave: %v", want, keyboard)
+	}
+
+	tds.Lock()
+	tds.Answers = make(map[int64]string)
+	tds.Unlock()
+
+	handleUpdate(bot, manager, callbackUpdate(Petrov, "q1", "assign_1:tasks:1:512"))
+
+	tds.Lock()
+	defer tds.Unlock()
+
+	if have := tds.Callbacks["q1"]; have != `Задача "написать бота" назначена на вас` {
+		t.Fatalf("bad callback answer: %q", have)
+	}
+	wantEdit := `1. написать бота by @ivanov
+assignee: я
+/unassign_1 /resolve_1
+
+2. прийти на хакатон by @ivanov
+/assign_2`
+	if have := tds.Edits[Petrov]; have != wantEdit {
+		t.Fatalf("bad edited message:\n\tWant: %v\n\tHave: %v", wantEdit, have)
+	}
+	want = [][]string{{"unassign_1:tasks:1:512", "resolve_1:tasks:1:512"}, {"assign_2:tasks:1:512"}}
+	if have := keyboardData(t, tds.Keyboards[Petrov]); !reflect.DeepEqual(have, want) {
+		t.Fatalf("bad edited keyboard:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+	wantAnswers := map[int64]string{
+		Ivanov: `Задача "написать бота" назначена на @ppetrov`,
+	}
+	if !reflect.DeepEqual(tds.Answers, wantAnswers) {
+		t.Fatalf("bad notifications:\n\tWant: %v\n\tHave: %v", wantAnswers, tds.Answers)
+	}
+}
+
+func TestInlineKeyboardResolveFromMy(t *testing.T) {
+	tds, bot := newTestBot(t)
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+
+	handleUpdate(bot, manager, messageUpdate(Ivanov, "/new написать бота"))
+	handleUpdate(bot, manager, messageUpdate(Petrov, "/assign_1"))
+	handleUpdate(bot, manager, callbackUpdate(Petrov, "q1", "resolve_1:my:1"))
+	handleUpdate(bot, manager, callbackUpdate(Petrov, "q2", "drop_tables"))
+
+	tds.Lock()
+	defer tds.Unlock()
+
+	if have := tds.Callbacks["q1"]; have != `Задача "написать бота" выполнена` {
+		t.Fatalf("bad callback answer: %q", have)
+	}
+	if have := tds.Edits[Petrov]; have != ru(msgNoYourTasks) {
+		t.Fatalf("bad edited message: %q", have)
+	}
+	if have := tds.Keyboards[Petrov]; have != "" {
+		t.Fatalf("keyboard left on empty list: %q", have)
+	}
+	if have := tds.Callbacks["q2"]; have != ru(msgUnknownCommand) {
+		t.Fatalf("bad answer on unknown button: %q", have)
+	}
+}
+
+func TestInlineKeyboardInGroup(t *testing.T) {
+	tds, bot := newTestBot(t)
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+
+	const backend int64 = -100500
+	group := &tgbotapi.Chat{ID: backend, Type: "group", Title: "backend"}
+	manager.touchBoard(backend, "backend", Ivanov)
+	manager.touchBoard(backend, "backend", Petrov)
+	manager.addTasks(backend, Ivanov, "ivanov", "/new написать бота")
+	manager.assignTasks(backend, "assign_1", Ivanov, "ivanov")
+
+	// Петров жмет кнопку под списком /my Иванова в общем чате
+	data := fmt.Sprintf("page:my:1:%d", Ivanov)
+	handleUpdate(bot, manager, tgbotapi.Update{
+		CallbackQuery: &tgbotapi.CallbackQuery{
+			ID:      "q1",
+			From:    users[Petrov],
+			Message: &tgbotapi.Message{MessageID: 42, Chat: group},
+			Data:    data,
+		},
+	})
+
+	tds.Lock()
+	defer tds.Unlock()
+	want := `1. написать бота by @ivanov
+/unassign_1 /resolve_1`
+	if have := tds.Edits[backend]; have != want {
+		t.Fatalf("list is redrawn for the wrong user:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	long := strings.Repeat("я", 300)
+	have := truncateText(long, maxCallbackTextLength)
+	if messageLength(have) != maxCallbackTextLength || !strings.HasSuffix(have, "…") {
+		t.Fatalf("bad truncated text: %d chars", messageLength(have))
+	}
+	if have := truncateText("готово", maxCallbackTextLength); have != "готово" {
+		t.Fatalf("short text is changed: %q", have)
+	}
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/skinass/telegram-bot-api/v5"
+)
+
+var createdRe = regexp.MustCompile(`id=(\d+)$`)
+
+func TestConcurrentNew(t *testing.T) {
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+
+	const n = 200
+	ids := make(chan int64, n)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp := manager.addTasks(personalBoardID, Ivanov, "ivanov", fmt.Sprintf("/new задача %d", i))
+			m := createdRe.FindStringSubmatch(resp)
+			if m == nil {
+				t.Errorf("unexpected response: %s", resp)
+				return
+			}
+			id, _ := strconv.ParseInt(m[1], 10, 64)
+			ids <- id
+		}(i)
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int64]bool, n)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("duplicate id %d", id)
+		}
+		seen[id] = true
+	}
+	for id := int64(1); id <= n; id++ {
+		if !seen[id] {
+			t.Fatalf("id %d was not issued", id)
+		}
+	}
+}
+
+func TestConcurrentAssign(t *testing.T) {
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+
+	const n = 100
+	for i := 0; i < n; i++ {
+		manager.addTasks(personalBoardID, Ivanov, "ivanov", fmt.Sprintf("/new задача %d", i))
+	}
+
+	// каждую задачу берет свой пользователь, параллельно с этим все
+	// пользователи читают списки задач
+	var wg sync.WaitGroup
+	for i := 1; i <= n; i++ {
+		wg.Add(2)
+		go func(id int64) {
+			defer wg.Done()
+			manager.assignTasks(personalBoardID, fmt.Sprintf("assign_%d", id), 1000+id, fmt.Sprintf("user%d", id))
+		}(int64(i))
+		go func(id int64) {
+			defer wg.Done()
+			manager.getAllTasks(personalBoardID, 1000+id)
+			manager.getMyTasks(personalBoardID, 1000+id)
+		}(int64(i))
+	}
+	wg.Wait()
+
+	for id := int64(1); id <= n; id++ {
+		task, ok := manager.storage.Get(id)
+		if !ok {
+			t.Fatalf("task %d lost", id)
+		}
+		if len(task.Assignees) != 1 || task.Assignees[0].ID != 1000+id {
+			t.Fatalf("assignment of task %d lost: %+v", id, task.Assignees)
+		}
+	}
+}
+
+func TestConcurrentAssignSameTask(t *testing.T) {
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new написать бота")
+
+	const n = 50
+	var wg sync.WaitGroup
+	for i := int64(1); i <= n; i++ {
+		wg.Add(1)
+		go func(userID int64) {
+			defer wg.Done()
+			manager.assignTasks(personalBoardID, "assign_1", userID, fmt.Sprintf("user%d", userID))
+		}(i)
+	}
+	wg.Wait()
+
+	task, _ := manager.storage.Get(1)
+	if len(task.Assignees) != 1 || task.Assignees[0].ID < 1 || task.Assignees[0].ID > n {
+		t.Fatalf("bad assignees after concurrent assign: %+v", task.Assignees)
+	}
+	for userID := int64(1); userID <= n; userID++ {
+		hasTask := manager.getMyTasks(personalBoardID, userID) != ru(msgNoYourTasks)
+		if hasTask != (userID == task.Assignees[0].ID) {
+			t.Fatalf("user %d: has task %v, assignee is %d", userID, hasTask, task.Assignees[0].ID)
+		}
+	}
+}
+
+func TestWorkersKeepChatOrder(t *testing.T) {
+	updates := make(chan tgbotapi.Update)
+
+	var mu sync.Mutex
+	got := make(map[int64][]int)
+
+	done := make(chan struct{})
+	go func() {
+		runWorkers(updates, 4, func(update tgbotapi.Update) {
+			mu.Lock()
+			defer mu.Unlock()
+			chatID := update.Message.Chat.ID
+			got[chatID] = append(got[chatID], update.UpdateID)
+		})
+		close(done)
+	}()
+
+	const perChat = 100
+	chats := []int64{Ivanov, Petrov, Alexandrov, -100500}
+	for i := 0; i < perChat; i++ {
+		for _, chatID := range chats {
+			updates <- tgbotapi.Update{
+				UpdateID: i,
+				Message:  &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}},
+			}
+		}
+	}
+	close(updates)
+	<-done
+
+	for _, chatID := range chats {
+		if len(got[chatID]) != perChat {
+			t.Fatalf("chat %d: got %d updates, want %d", chatID, len(got[chatID]), perChat)
+		}
+		for i, id := range got[chatID] {
+			if id != i {
+				t.Fatalf("chat %d: update %d handled at position %d", chatID, id, i)
+			}
+		}
+	}
+}
+
+func TestWorkersSlowChat(t *testing.T) {
+	updates := make(chan tgbotapi.Update)
+	release := make(chan struct{})
+	handled := make(chan int64, 1)
+
+	done := make(chan struct{})
+	go func() {
+		runWorkers(updates, 2, func(update tgbotapi.Update) {
+			chatID := update.Message.Chat.ID
+			if chatID == Ivanov {
+				<-release
+				return
+			}
+			handled <- chatID
+		})
+		close(done)
+	}()
+
+	// чат Иванова завис на отправке, а апдейты из него все приходят
+	for i := 0; i < 100; i++ {
+		updates <- tgbotapi.Update{UpdateID: i, Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: Ivanov}}}
+	}
+	updates <- tgbotapi.Update{UpdateID: 100, Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: Petrov}}}
+
+	select {
+	case chatID := <-handled:
+		if chatID != Petrov {
+			t.Fatalf("handled chat %d, want %d", chatID, Petrov)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("slow chat blocks other chats")
+	}
+
+	close(release)
+	close(updates)
+	<-done
+}
+
+// fakeClock - управляемое из теста время: After срабатывает только
+// когда тест продвигает часы через Advance
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = waiters
+}
+
+// WaitWaiters ждет, пока кто-нибудь не начнет ждать на After
+func (c *fakeClock) WaitWaiters(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		n := len(c.waiters)
+		c.mu.Unlock()
+		if n > 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("nobody waits on fake clock")
+}
+
+func TestResolveHistory(t *testing.T) {
+	clock := newFakeClock(time.Date(2026, 10, 17, 12, 0, 0, 0, time.Local))
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.clock = clock
+
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new написать бота")
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new прийти на хакатон")
+	manager.assignTasks(personalBoardID, "assign_1", Petrov, "ppetrov")
+
+	if have := manager.getDoneTasks(personalBoardID, Ivanov); have != ru(msgNoDoneTasks) {
+		t.Fatalf("bad /done before resolve: %s", have)
+	}
+
+	my, notifications := manager.resolveTasks(personalBoardID, "resolve_1", "бот готов", Petrov, "ppetrov")
+	wantNotifications := []notification{{Ivanov, "Задача \"написать бота\" выполнена @ppetrov\nкомментарий: бот готов"}}
+	if my != `Задача "написать бота" выполнена` || !reflect.DeepEqual(notifications, wantNotifications) {
+		t.Fatalf("bad resolve responses: %q %v", my, notifications)
+	}
+
+	clock.Advance(time.Hour)
+	manager.resolveTasks(personalBoardID, "resolve_2", "", Ivanov, "ivanov")
+
+	want := `2. прийти на хакатон by @ivanov
+выполнена @ivanov 17.10.2026 13:00
+/reopen_2
+
+1. написать бота by @ivanov
+выполнена @ppetrov 17.10.2026 12:00
+комментарий: бот готов
+/reopen_1`
+	if have := manager.getDoneTasks(personalBoardID, Ivanov); have != want {
+		t.Fatalf("bad /done:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+	if have := manager.getAllTasks(personalBoardID, Ivanov); have != ru(msgNoTasks) {
+		t.Fatalf("resolved tasks are listed in /tasks: %s", have)
+	}
+	if my, _ := manager.assignTasks(personalBoardID, "assign_1", Petrov, "ppetrov"); my != ru(msgLogNoTasks) {
+		t.Fatalf("resolved task was assigned: %s", my)
+	}
+
+	my, notifications = manager.reopenTasks(personalBoardID, "reopen_1", Petrov, "ppetrov")
+	wantNotifications = []notification{{Ivanov, `Задача "написать бота" снова открыта @ppetrov`}}
+	if my != `Задача "написать бота" снова открыта` || !reflect.DeepEqual(notifications, wantNotifications) {
+		t.Fatalf("bad reopen responses: %q %v", my, notifications)
+	}
+	if my, _ := manager.reopenTasks(personalBoardID, "reopen_1", Petrov, "ppetrov"); my != ru(msgNotResolved) {
+		t.Fatalf("bad second reopen response: %s", my)
+	}
+	// кто закрывал задачу, остается известно и после /reopen
+	task, _ := manager.storage.Get(1)
+	if len(task.Resolutions) != 1 || task.Resolutions[0].By.ID != Petrov || task.Resolutions[0].Note != "бот готов" ||
+		task.Resolutions[0].ReopenedBy == nil || task.Resolutions[0].ReopenedBy.ID != Petrov {
+		t.Fatalf("bad resolutions after reopen: %+v", task.Resolutions)
+	}
+
+	want = `1. написать бота by @ivanov
+/assign_1`
+	if have := manager.getOwnTasks(personalBoardID, Ivanov); have != want {
+		t.Fatalf("bad /owner after reopen:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+}
+
+func TestBoards(t *testing.T) {
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+
+	const backend int64 = -1001
+	private := &tgbotapi.Chat{ID: Ivanov, Type: "private"}
+	group := &tgbotapi.Chat{ID: backend, Type: "supergroup", Title: "Backend"}
+
+	if boardID, _ := boardForChat(private); boardID != personalBoardID {
+		t.Fatalf("private chat uses board %d", boardID)
+	}
+	if boardID, title := boardForChat(group); boardID != backend || title != "Backend" {
+		t.Fatalf("group chat uses board %d %q", boardID, title)
+	}
+
+	manager.touchBoard(personalBoardID, personalBoardTitle, Ivanov)
+	manager.touchBoard(backend, "Backend", Ivanov)
+	manager.touchBoard(backend, "Backend", Petrov)
+
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new личная задача")
+	manager.addTasks(backend, Ivanov, "ivanov", "/new@taskbot поднять базу")
+	manager.addTasks(backend, Petrov, "ppetrov", "/new написать миграции")
+
+	want := `2. поднять базу by @ivanov
+/assign_2
+
+3. написать миграции by @ppetrov
+/assign_3`
+	if have := manager.getAllTasks(backend, Ivanov); have != want {
+		t.Fatalf("bad group /tasks:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+
+	want = `1. личная задача by @ivanov
+/assign_1`
+	if have := manager.getAllTasks(personalBoardID, Ivanov); have != want {
+		t.Fatalf("bad private /tasks:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+
+	// задачи другой доски нельзя взять из этого чата
+	if my, _ := manager.assignTasks(personalBoardID, "assign_2", Ivanov, "ivanov"); my != ru(msgLogNoTasks) {
+		t.Fatalf("task from another board was assigned: %s", my)
+	}
+	manager.assignTasks(backend, "assign_2", Ivanov, "ivanov")
+	if have := manager.getMyTasks(personalBoardID, Ivanov); have != ru(msgNoYourTasks) {
+		t.Fatalf("bad private /my: %s", have)
+	}
+	if have := manager.getOwnTasks(personalBoardID, Petrov); have != ru(msgNoCreatedTasks) {
+		t.Fatalf("bad private /owner: %s", have)
+	}
+
+	want = `Ваши доски:
+Личные сообщения - задач: 1
+Backend - задач: 2`
+	if have := manager.getBoards(Ivanov); have != want {
+		t.Fatalf("bad /boards:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+	if have := manager.getBoards(Alexandrov); have != ru(msgNoBoards) {
+		t.Fatalf("bad /boards for stranger: %s", have)
+	}
+}
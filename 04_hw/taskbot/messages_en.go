@@ -0,0 +1,174 @@
+package main
+
+var catalogEn = map[string]string{
+	msgGreeting:       "Hi! I'm your task manager!",
+	msgNoTasks:        "No tasks",
+	msgNotAssignee:    "The task is not assigned to you",
+	msgAccepted:       "Accepted",
+	msgNoYourTasks:    "You have no tasks",
+	msgNoCreatedTasks: "You have not created any tasks",
+	msgUnknownCommand: "I don't know this command",
+	msgLogNoTasks:     "The task does not exist",
+	msgStorageError:   "Could not save the changes, please try again later",
+	msgNoDoneTasks:    "No resolved tasks",
+	msgNotResolved:    "The task is not resolved yet",
+	msgEmptyTitle:     "Please specify the task title",
+	msgBadDue:         "Could not parse the due date, use due:2026-11-01 or due:+3d",
+	msgPage:           "\n\npage %d of %d",
+	msgNextPage:       ", next: %s %d",
+	msgTaskCreated:    `Task "%s" created, id=%d`,
+	msgAssignedToYou:  `Task "%s" is assigned to you`,
+	msgUnassignedBy:   `You were unassigned from task "%s" by @%s`,
+	msgNoAssigneeLeft: `Task "%s" has no assignee now`,
+	msgAssigneeLeft:   `@%s is no longer an assignee of task "%s"`,
+	msgResolved:       `Task "%s" is resolved`,
+	msgResolvedBy:     `Task "%s" is resolved by @%s`,
+	msgResolutionNote: "\ncomment: %s",
+	msgDoneBy:         "\nresolved by @%s %s",
+	msgReopened:       `Task "%s" is reopened`,
+	msgReopenedBy:     `Task "%s" is reopened by @%s`,
+
+	msgPriority:   "\npriority: %s",
+	msgDue:        "\ndue: %s",
+	msgTags:       "\ntags: %s",
+	msgPrioLow:    "low",
+	msgPrioNormal: "normal",
+	msgPrioHigh:   "high",
+
+	msgAlreadyAssignee: "You are already an assignee of this task",
+	msgAlreadyWatcher:  "You are already watching this task",
+	msgNotWatcher:      "You are not watching this task",
+	msgMe:              "me",
+	msgJoined:          `You joined task "%s"`,
+	msgJoinedBy:        `@%s is now also an assignee of task "%s"`,
+	msgWatching:        `You are watching task "%s"`,
+	msgUnwatched:       `You are no longer watching task "%s"`,
+
+	msgPersonalBoard: "Private messages",
+	msgNoBoards:      "You are not a member of any board yet",
+	msgBoards:        "Your boards:",
+	msgBoardRow:      "%s - tasks: %d",
+
+	msgCommentUsage: "Write the comment text: /comment_$ID text",
+	msgCommentAdded: `Comment added to task "%s"`,
+	msgCommentBy:    "@%s on task \"%s\":\n%s",
+	msgNoComments:   "\n\nno comments yet, /comment_%d text",
+	msgComments:     "\n\ncomments:",
+
+	msgButtonAssign:   "Take",
+	msgButtonUnassign: "Drop",
+	msgButtonResolve:  "Resolve",
+	msgButtonPrev:     "« back",
+	msgButtonNext:     "next »",
+
+	msgForbidden:   "Not enough permissions",
+	msgNoAssignees: "The task has no assignee",
+
+	msgOverdue: `Task "%s" is overdue, it was due %s`,
+	msgDueSoon: `Task "%s" is due %s`,
+
+	msgAdminUsage:    "Usage: /admin @username",
+	msgRoleUsage:     "Usage: /role @username admin|member|viewer",
+	msgReadOnly:      "You have read-only access",
+	msgLastAdmin:     "The board must keep at least one admin",
+	msgRoleAdmin:     "admin",
+	msgRoleMember:    "member",
+	msgRoleViewer:    "viewer",
+	msgYourRole:      "Your role on the board: %s",
+	msgRoleSet:       "Role of @%s on the board: %s",
+	msgRoleChanged:   `Your role on board "%s": %s`,
+	msgTaskDeleted:   `Task "%s" deleted`,
+	msgTaskDeletedBy: `Task "%s" deleted by @%s`,
+
+	msgFindUsage:    "Usage: /find words or #tag [assignee:@user] [owner:me] [unassigned]",
+	msgNothingFound: "Nothing found",
+
+	msgTagUsage:   "Specify tags: /tag_$ID backend infra",
+	msgBadTag:     "A tag may contain only letters, digits, _ and -",
+	msgNoTagsLeft: `Task "%s" has no tags now`,
+	msgTaskTags:   `Tags of task "%s": %s`,
+
+	msgUnknownUser:     "User @%s not found, they must write to the bot at least once",
+	msgNotMember:       "User @%s does not work with this board",
+	msgAssignedTo:      `Task "%s" is assigned to @%s`,
+	msgAssignedToYouBy: `Task "%s" is assigned to you by @%s`,
+
+	msgAuditHeader: "History of task %d:",
+	msgNoHistory:   "Task %d has no history of changes",
+
+	msgActionCreated:        "created",
+	msgActionAssigned:       "assigned",
+	msgActionJoined:         "joined assignees",
+	msgActionUnassigned:     "unassigned",
+	msgActionWatcherAdded:   "started watching",
+	msgActionWatcherRemoved: "stopped watching",
+	msgActionResolved:       "resolved",
+	msgActionReopened:       "reopened",
+	msgActionCommented:      "commented",
+	msgActionTagsChanged:    "changed tags",
+	msgActionDeleted:        "deleted",
+
+	msgFieldTitle:       "title",
+	msgFieldDescription: "description",
+	msgFieldDue:         "due",
+	msgFieldPriority:    "priority",
+	msgFieldTags:        "tags",
+	msgFieldAssignees:   "assignees",
+	msgFieldWatchers:    "watchers",
+	msgFieldResolution:  "resolution",
+	msgFieldComment:     "comment",
+
+	msgLangUsage: "Usage: /lang ru|en",
+	msgLangSet:   "Language: English",
+
+	msgDigestUsage:      "Usage: /digest 09:00 or /digest off",
+	msgDigestSet:        "I will send you a task digest every day at %s",
+	msgDigestOff:        "Task digest is off",
+	msgDigestNone:       "Task digest is off, turn it on with /digest 09:00",
+	msgDigestHeader:     "Task digest for %s:",
+	msgDigestAssigned:   "\n\nAssigned to you:",
+	msgDigestUnassigned: "\n\nYour tasks without assignees:",
+	msgDigestOverdue:    "\n\nOverdue:",
+	msgDigestRow:        "\n%d. %s",
+	msgDigestOverdueRow: "\n%d. %s, was due %s",
+
+	msgHelpIntro:    "Here are my commands:",
+	msgHelpHelp:     "show this help",
+	msgHelpTasks:    "show all tasks",
+	msgHelpNew:      "create a new task\n  the first line may set a due date due:2026-11-01 or due:+3d,\n  a priority !low, !normal, !high and tags #backend, the following lines are the description",
+	msgHelpAssign:   "take the task or assign it to another user",
+	msgHelpUnassign: "drop the task (a board admin removes all assignees)",
+	msgHelpJoin:     "become one more assignee of the task",
+	msgHelpLeave:    "stop being an assignee of the task",
+	msgHelpWatch:    "watch the task changes",
+	msgHelpUnwatch:  "stop watching the task",
+	msgHelpResolve:  "resolve the task and remove it from the list",
+	msgHelpReopen:   "reopen a resolved task",
+	msgHelpTag:      "add tags to the task",
+	msgHelpUntag:    "remove tags from the task",
+	msgHelpComment:  "comment on the task",
+	msgHelpShow:     "show the task with all comments",
+	msgHelpLog:      "show the history of task changes",
+	msgHelpMy:       "show tasks assigned to me",
+	msgHelpOwner:    "show tasks created by me",
+	msgHelpDone:     "show recently resolved tasks",
+	msgHelpFind:     "find tasks",
+	msgHelpBoards:   "show boards I am a member of",
+	msgHelpRole:     "show my role on the board or change someone's role",
+	msgHelpAdmin:    "make the user a board admin",
+	msgHelpDelete:   "delete the task (admins only)",
+	msgHelpLang:     "choose the bot language",
+	msgHelpDigest:   "get a daily task digest at the given time",
+
+	msgArgsList:    "[#tag] [page]",
+	msgArgsNew:     "XXX YYY ZZZ",
+	msgArgsMention: "@username",
+	msgArgsAssign:  "[@username]",
+	msgArgsNote:    "[comment]",
+	msgArgsTag:     "tag",
+	msgArgsText:    "text",
+	msgArgsFind:    "words [assignee:@user] [owner:me] [unassigned]",
+	msgArgsRole:    "[@username admin|member|viewer]",
+	msgArgsLang:    "[ru|en]",
+	msgArgsDigest:  "[09:00|off]",
+}
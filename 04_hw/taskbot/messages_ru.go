@@ -0,0 +1,174 @@
+package main
+
+var catalogRu = map[string]string{
+	msgGreeting:       "Привет! Я твой менеджер задач!",
+	msgNoTasks:        "Нет задач",
+	msgNotAssignee:    "Задача не на вас",
+	msgAccepted:       "Принято",
+	msgNoYourTasks:    "У вас нет задач",
+	msgNoCreatedTasks: "Вы не создавали задачи",
+	msgUnknownCommand: "Я не знаю такую команду",
+	msgLogNoTasks:     "Задачи не существует",
+	msgStorageError:   "Не удалось сохранить изменения, попробуйте позже",
+	msgNoDoneTasks:    "Нет выполненных задач",
+	msgNotResolved:    "Задача еще не выполнена",
+	msgEmptyTitle:     "Укажите название задачи",
+	msgBadDue:         "Не понял срок, используйте due:2026-11-01 или due:+3d",
+	msgPage:           "\n\nстраница %d из %d",
+	msgNextPage:       ", следующая: %s %d",
+	msgTaskCreated:    `Задача "%s" создана, id=%d`,
+	msgAssignedToYou:  `Задача "%s" назначена на вас`,
+	msgUnassignedBy:   `Задача "%s" снята с вас пользователем @%s`,
+	msgNoAssigneeLeft: `Задача "%s" осталась без исполнителя`,
+	msgAssigneeLeft:   `@%s больше не исполнитель задачи "%s"`,
+	msgResolved:       `Задача "%s" выполнена`,
+	msgResolvedBy:     `Задача "%s" выполнена @%s`,
+	msgResolutionNote: "\nкомментарий: %s",
+	msgDoneBy:         "\nвыполнена @%s %s",
+	msgReopened:       `Задача "%s" снова открыта`,
+	msgReopenedBy:     `Задача "%s" снова открыта @%s`,
+
+	msgPriority:   "\nприоритет: %s",
+	msgDue:        "\nсрок: %s",
+	msgTags:       "\nтеги: %s",
+	msgPrioLow:    "низкий",
+	msgPrioNormal: "обычный",
+	msgPrioHigh:   "высокий",
+
+	msgAlreadyAssignee: "Вы уже исполнитель этой задачи",
+	msgAlreadyWatcher:  "Вы уже следите за этой задачей",
+	msgNotWatcher:      "Вы не следите за этой задачей",
+	msgMe:              "я",
+	msgJoined:          `Вы присоединились к задаче "%s"`,
+	msgJoinedBy:        `@%s теперь тоже исполнитель задачи "%s"`,
+	msgWatching:        `Вы следите за задачей "%s"`,
+	msgUnwatched:       `Вы больше не следите за задачей "%s"`,
+
+	msgPersonalBoard: "Личные сообщения",
+	msgNoBoards:      "Вы пока не участвуете ни в одной доске",
+	msgBoards:        "Ваши доски:",
+	msgBoardRow:      "%s - задач: %d",
+
+	msgCommentUsage: "Напишите текст комментария: /comment_$ID текст",
+	msgCommentAdded: `Комментарий к задаче "%s" добавлен`,
+	msgCommentBy:    "@%s к задаче \"%s\":\n%s",
+	msgNoComments:   "\n\nкомментариев нет, /comment_%d текст",
+	msgComments:     "\n\nкомментарии:",
+
+	msgButtonAssign:   "Взять",
+	msgButtonUnassign: "Отказаться",
+	msgButtonResolve:  "Выполнить",
+	msgButtonPrev:     "« назад",
+	msgButtonNext:     "вперед »",
+
+	msgForbidden:   "Недостаточно прав",
+	msgNoAssignees: "У задачи нет исполнителя",
+
+	msgOverdue: `Задача "%s" просрочена, срок был %s`,
+	msgDueSoon: `Срок задачи "%s" истекает %s`,
+
+	msgAdminUsage:    "Используйте: /admin @username",
+	msgRoleUsage:     "Используйте: /role @username admin|member|viewer",
+	msgReadOnly:      "У вас доступ только на чтение",
+	msgLastAdmin:     "На доске должен остаться хотя бы один администратор",
+	msgRoleAdmin:     "администратор",
+	msgRoleMember:    "участник",
+	msgRoleViewer:    "читатель",
+	msgYourRole:      "Ваша роль на доске: %s",
+	msgRoleSet:       "Роль @%s на доске: %s",
+	msgRoleChanged:   `Ваша роль на доске "%s": %s`,
+	msgTaskDeleted:   `Задача "%s" удалена`,
+	msgTaskDeletedBy: `Задача "%s" удалена @%s`,
+
+	msgFindUsage:    "Использование: /find слова или #тег [assignee:@user] [owner:me] [unassigned]",
+	msgNothingFound: "Ничего не найдено",
+
+	msgTagUsage:   "Укажите теги: /tag_$ID backend infra",
+	msgBadTag:     "Тег может состоять только из букв, цифр, _ и -",
+	msgNoTagsLeft: `У задачи "%s" больше нет тегов`,
+	msgTaskTags:   `Теги задачи "%s": %s`,
+
+	msgUnknownUser:     "Пользователь @%s не найден, он должен хотя бы раз написать боту",
+	msgNotMember:       "Пользователь @%s не работает с этой доской",
+	msgAssignedTo:      `Задача "%s" назначена на @%s`,
+	msgAssignedToYouBy: `Задача "%s" назначена на вас пользователем @%s`,
+
+	msgAuditHeader: "История задачи %d:",
+	msgNoHistory:   "У задачи %d нет истории изменений",
+
+	msgActionCreated:        "создание",
+	msgActionAssigned:       "назначение",
+	msgActionJoined:         "присоединение к исполнителям",
+	msgActionUnassigned:     "снятие исполнителя",
+	msgActionWatcherAdded:   "подписка",
+	msgActionWatcherRemoved: "отписка",
+	msgActionResolved:       "выполнение",
+	msgActionReopened:       "возврат в работу",
+	msgActionCommented:      "комментарий",
+	msgActionTagsChanged:    "изменение тегов",
+	msgActionDeleted:        "удаление",
+
+	msgFieldTitle:       "название",
+	msgFieldDescription: "описание",
+	msgFieldDue:         "срок",
+	msgFieldPriority:    "приоритет",
+	msgFieldTags:        "теги",
+	msgFieldAssignees:   "исполнители",
+	msgFieldWatchers:    "наблюдатели",
+	msgFieldResolution:  "выполнение",
+	msgFieldComment:     "комментарий",
+
+	msgLangUsage: "Используйте: /lang ru|en",
+	msgLangSet:   "Язык: русский",
+
+	msgDigestUsage:      "Используйте: /digest 09:00 или /digest off",
+	msgDigestSet:        "Каждый день в %s пришлю сводку задач",
+	msgDigestOff:        "Сводка задач выключена",
+	msgDigestNone:       "Сводка задач выключена, включить: /digest 09:00",
+	msgDigestHeader:     "Сводка задач на %s:",
+	msgDigestAssigned:   "\n\nНа вас:",
+	msgDigestUnassigned: "\n\nВаши задачи без исполнителя:",
+	msgDigestOverdue:    "\n\nПросрочено:",
+	msgDigestRow:        "\n%d. %s",
+	msgDigestOverdueRow: "\n%d. %s, срок был %s",
+
+	msgHelpIntro:    "Вот мои команды:",
+	msgHelpHelp:     "показать эту справку",
+	msgHelpTasks:    "посмотреть все задачи",
+	msgHelpNew:      "создать новую задачу\n  в первой строке можно указать срок due:2026-11-01 или due:+3d,\n  приоритет !low, !normal, !high и теги #backend, следующие строки - описание",
+	msgHelpAssign:   "взять задачу себе или назначить ее на другого пользователя",
+	msgHelpUnassign: "снять задачу с себя (администратор доски снимает всех исполнителей)",
+	msgHelpJoin:     "стать еще одним исполнителем задачи",
+	msgHelpLeave:    "перестать быть исполнителем задачи",
+	msgHelpWatch:    "следить за изменениями задачи",
+	msgHelpUnwatch:  "перестать следить за задачей",
+	msgHelpResolve:  "выполнить задачу, убрать ее из списка",
+	msgHelpReopen:   "вернуть выполненную задачу в работу",
+	msgHelpTag:      "добавить задаче теги",
+	msgHelpUntag:    "убрать у задачи теги",
+	msgHelpComment:  "прокомментировать задачу",
+	msgHelpShow:     "показать задачу со всеми комментариями",
+	msgHelpLog:      "показать историю изменений задачи",
+	msgHelpMy:       "показать задачи, которые мне поручены",
+	msgHelpOwner:    "показать задачи, которые были созданы мной",
+	msgHelpDone:     "показать недавно выполненные задачи",
+	msgHelpFind:     "найти задачи",
+	msgHelpBoards:   "показать доски, в которых я участвую",
+	msgHelpRole:     "показать свою роль на доске или поменять чужую",
+	msgHelpAdmin:    "сделать пользователя администратором доски",
+	msgHelpDelete:   "удалить задачу (только для администраторов)",
+	msgHelpLang:     "выбрать язык бота",
+	msgHelpDigest:   "получать каждый день сводку задач в указанное время",
+
+	msgArgsList:    "[#тег] [страница]",
+	msgArgsNew:     "XXX YYY ZZZ",
+	msgArgsMention: "@username",
+	msgArgsAssign:  "[@username]",
+	msgArgsNote:    "[комментарий]",
+	msgArgsTag:     "тег",
+	msgArgsText:    "текст",
+	msgArgsFind:    "слова [assignee:@user] [owner:me] [unassigned]",
+	msgArgsRole:    "[@username admin|member|viewer]",
+	msgArgsLang:    "[ru|en]",
+	msgArgsDigest:  "[09:00|off]",
+}
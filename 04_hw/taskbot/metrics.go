@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/skinass/telegram-bot-api/v5"
+)
+
+// metrics - счетчики бота, /metrics отдает их в текстовом формате Prometheus.
+// Как и в prometheus/client_golang, счетчики общие на весь процесс
+var metrics = newBotMetrics()
+
+// границы бакетов гистограммы времени обработки, в секундах
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// виды отправок для taskbot_send_failures_total
+const (
+	sendMessage      = "message"
+	sendNotification = "notification"
+	sendReminder     = "reminder"
+	sendDigest       = "digest"
+	sendEdit         = "edit"
+	sendCallback     = "callback"
+)
+
+// commandUnknown - метка для сообщений и кнопок, которые не разобрались в команду,
+// чтобы произвольный текст не плодил новые ряды метрик
+const commandUnknown = "unknown"
+
+type histogram struct {
+	// counts[i] - сколько наблюдений попало в (latencyBuckets[i-1], latencyBuckets[i]]
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+type botMetrics struct {
+	mu           sync.Mutex
+	updates      map[string]uint64
+	sendFailures map[string]uint64
+	latency      map[string]*histogram
+}
+
+func newBotMetrics() *botMetrics {
+	return &botMetrics{
+		updates:      make(map[string]uint64),
+		sendFailures: make(map[string]uint64),
+		latency:      make(map[string]*histogram),
+	}
+}
+
+// observeUpdate учитывает обработанный апдейт и время его обработки
+func (m *botMetrics) observeUpdate(command string, took time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.updates[command]++
+
+	h, ok := m.latency[command]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(latencyBuckets))}
+		m.latency[command] = h
+	}
+	seconds := took.Seconds()
+	h.sum += seconds
+	h.count++
+	if i := sort.SearchFloat64s(latencyBuckets, seconds); i < len(latencyBuckets) {
+		h.counts[i]++
+	}
+}
+
+func (m *botMetrics) sendFailed(kind string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sendFailures[kind]++
+}
+
+// write пишет все метрики в формате Prometheus, openTasks - число открытых задач по доскам
+func (m *botMetrics) write(w io.Writer, openTasks map[int64]int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p := &metricsPrinter{w: w}
+
+	p.header("taskbot_updates_total", "counter", "Updates handled, by command.")
+	for _, command := range sortedKeys(m.updates) {
+		p.line("taskbot_updates_total", labels("command", command), float64(m.updates[command]))
+	}
+
+	p.header("taskbot_send_failures_total", "counter", "Messages that could not be sent to Telegram, by kind.")
+	for _, kind := range sortedKeys(m.sendFailures) {
+		p.line("taskbot_send_failures_total", labels("kind", kind), float64(m.sendFailures[kind]))
+	}
+
+	p.header("taskbot_open_tasks", "gauge", "Open tasks, by board.")
+	boards := make([]int64, 0, len(openTasks))
+	for boardID := range openTasks {
+		boards = append(boards, boardID)
+	}
+	sort.Slice(boards, func(i, j int) bool { return boards[i] < boards[j] })
+	for _, boardID := range boards {
+		p.line("taskbot_open_tasks", labels("board", strconv.FormatInt(boardID, 10)), float64(openTasks[boardID]))
+	}
+
+	p.header("taskbot_update_duration_seconds", "histogram", "Update handling latency, by command.")
+	for _, command := range sortedKeys(m.latency) {
+		h := m.latency[command]
+		var cumulative uint64
+		for i, le := range latencyBuckets {
+			cumulative += h.counts[i]
+			p.line("taskbot_update_duration_seconds_bucket",
+				labels("command", command, "le", strconv.FormatFloat(le, 'g', -1, 64)), float64(cumulative))
+		}
+		p.line("taskbot_update_duration_seconds_bucket", labels("command", command, "le", "+Inf"), float64(h.count))
+		p.line("taskbot_update_duration_seconds_sum", labels("command", command), h.sum)
+		p.line("taskbot_update_duration_seconds_count", labels("command", command), float64(h.count))
+	}
+
+	return p.err
+}
+
+// metricsPrinter запоминает первую ошибку записи, чтобы не проверять каждую строку
+type metricsPrinter struct {
+	w   io.Writer
+	err error
+}
+
+func (p *metricsPrinter) header(name, kind, help string) {
+	p.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (p *metricsPrinter) line(name, labels string, value float64) {
+	p.printf("%s%s %s\n", name, labels, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (p *metricsPrinter) printf(format string, args ...any) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = fmt.Fprintf(p.w, format, args...)
+}
+
+// labels собирает {name="value",...} из пар имя-значение
+func labels(pairs ...string) string {
+	result := "{"
+	for i := 0; i < len(pairs); i += 2 {
+		if i > 0 {
+			result += ","
+		}
+		result += pairs[i] + "=" + strconv.Quote(pairs[i+1])
+	}
+	return result + "}"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// updateCommand - метка команды для апдейта: имя команды из реестра без id задачи
+func updateCommand(update tgbotapi.Update) string {
+	var text string
+	switch {
+	case update.CallbackQuery != nil:
+		cb, ok := parseCallbackData(update.CallbackQuery.Data)
+		if !ok {
+			return commandUnknown
+		}
+		if cb.Command == "page" {
+			return cb.Command
+		}
+		text = cb.Command
+	case update.Message != nil:
+		text = update.Message.Command()
+	}
+
+	cmd, ok := botCommands.lookup(text)
+	if !ok {
+		return commandUnknown
+	}
+	return cmd.Name
+}
+
+// openTasksByBoard - число открытых задач на каждой известной доске
+func (tm *TaskManager) openTasksByBoard() map[int64]int {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	result := make(map[int64]int)
+	for _, board := range tm.storage.Boards() {
+		result[board.ID] = 0
+	}
+	for _, task := range tm.getOpenTasks() {
+		result[task.BoardID]++
+	}
+	return result
+}
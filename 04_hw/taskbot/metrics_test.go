@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsFormat(t *testing.T) {
+	m := newBotMetrics()
+	m.observeUpdate("new", 250*time.Millisecond)
+	m.observeUpdate("new", 2*time.Second)
+	m.observeUpdate(commandUnknown, time.Millisecond)
+	m.sendFailed(sendNotification)
+
+	var buf bytes.Buffer
+	if err := m.write(&buf, map[int64]int{0: 2, -100: 0}); err != nil {
+		t.Fatalf("write error: %s", err)
+	}
+
+	want := `# HELP taskbot_updates_total Updates handled, by command.
+# TYPE taskbot_updates_total counter
+taskbot_updates_total{command="new"} 2
+taskbot_updates_total{command="unknown"} 1
+# HELP taskbot_send_failures_total Messages that could not be sent to Telegram, by kind.
+# TYPE taskbot_send_failures_total counter
+taskbot_send_failures_total{kind="notification"} 1
+# HELP taskbot_open_tasks Open tasks, by board.
+# TYPE taskbot_open_tasks gauge
+taskbot_open_tasks{board="-100"} 0
+taskbot_open_tasks{board="0"} 2
+# HELP taskbot_update_duration_seconds Update handling latency, by command.
+# TYPE taskbot_update_duration_seconds histogram
+`
+	for _, le := range []string{"0.005", "0.01", "0.025", "0.05", "0.1"} {
+		want += `taskbot_update_duration_seconds_bucket{command="new",le="` + le + `"} 0` + "\n"
+	}
+	for _, le := range []string{"0.25", "0.5", "1"} {
+		want += `taskbot_update_duration_seconds_bucket{command="new",le="` + le + `"} 1` + "\n"
+	}
+	for _, le := range []string{"2.5", "5", "10", "+Inf"} {
+		want += `taskbot_update_duration_seconds_bucket{command="new",le="` + le + `"} 2` + "\n"
+	}
+	want += `taskbot_update_duration_seconds_sum{command="new"} 2.25
+taskbot_update_duration_seconds_count{command="new"} 2
+`
+	if have := buf.String(); !strings.HasPrefix(have, want) {
+		t.Fatalf("bad metrics:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+	if !strings.Contains(buf.String(), `taskbot_update_duration_seconds_bucket{command="unknown",le="0.005"} 1`) {
+		t.Fatalf("no histogram for unknown commands:\n%s", buf.String())
+	}
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	_, bot := newTestBot(t)
+
+	storage, err := OpenFileStorage(filepath.Join(t.TempDir(), "tasks.journal"))
+	if err != nil {
+		t.Fatalf("OpenFileStorage error: %s", err)
+	}
+	manager := newTelegramPresenter(NewTaskManager(storage))
+
+	var status botStatus
+	ts := httptest.NewServer(newHTTPServer("", manager.TaskManager, &status, nil, nil).Handler)
+	defer ts.Close()
+
+	get := func(path string, wantStatus int) string {
+		t.Helper()
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s error: %s", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != wantStatus {
+			t.Fatalf("GET %s: want status %d, have %d %s", path, wantStatus, resp.StatusCode, body)
+		}
+		return string(body)
+	}
+
+	get("/healthz", http.StatusOK)
+	// вебхук еще не зарегистрирован
+	get("/readyz", http.StatusServiceUnavailable)
+
+	status.receiving.Store(true)
+	get("/readyz", http.StatusOK)
+
+	handleUpdate(bot, manager, messageUpdate(Ivanov, "/new написать бота"))
+	handleUpdate(bot, manager, messageUpdate(Ivanov, "/tasks_42"))
+	body := get("/metrics", http.StatusOK)
+	for _, want := range []string{
+		"taskbot_open_tasks{board=\"0\"} 1\n",
+		"taskbot_updates_total{command=\"new\"} ",
+		"taskbot_updates_total{command=\"unknown\"} ",
+		"taskbot_update_duration_seconds_count{command=\"new\"} ",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("metrics miss %q:\n%s", want, body)
+		}
+	}
+
+	// журнал закрыт - сохранять задачи некуда
+	storage.Close()
+	get("/readyz", http.StatusServiceUnavailable)
+	get("/healthz", http.StatusOK)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/skinass/telegram-bot-api/v5"
+)
+
+const (
+	defaultPageSize = 10
+	// телеграм не принимает сообщения длиннее 4096 символов (в UTF-16)
+	maxMessageLength = 4096
+)
+
+// paginate возвращает задачи страницы page (нумерация с 1), номер страницы,
+// приведенный к существующему, и общее число страниц
+func paginate(tasks []Task, page, pageSize int) ([]Task, int, int) {
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+
+	pages := (len(tasks) + pageSize - 1) / pageSize
+	if pages < 1 {
+		pages = 1
+	}
+	if page < 1 {
+		page = 1
+	}
+	if page > pages {
+		page = pages
+	}
+
+	start := (page - 1) * pageSize
+	end := start + pageSize
+	if end > len(tasks) {
+		end = len(tasks)
+	}
+
+	return tasks[start:end], page, pages
+}
+
+// parseListArgs достает из аргументов команды фильтр по тегу и номер страницы:
+// /tasks #backend 2
+func parseListArgs(view, args string) (string, int) {
+	page := 1
+	for _, word := range strings.Fields(args) {
+		if strings.HasPrefix(word, "#") {
+			if tag, ok := parseTag(word); ok {
+				view = view + "#" + tag
+			}
+			continue
+		}
+		if n, err := strconv.Atoi(word); err == nil {
+			page = n
+		}
+	}
+	return view, page
+}
+
+func pageButtons(view string, page, pages int, userID int64, lang string) []tgbotapi.InlineKeyboardButton {
+	var row []tgbotapi.InlineKeyboardButton
+	if page > 1 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(tr(lang, msgButtonPrev), callbackData("page", view, page-1, userID)))
+	}
+	if page < pages {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(tr(lang, msgButtonNext), callbackData("page", view, page+1, userID)))
+	}
+	return row
+}
+
+// sendText отправляет ответ, при необходимости разбивая его на несколько
+// сообщений. Клавиатура прикрепляется к последнему из них.
+func sendText(bot Sender, chatID int64, text string, keyboard *tgbotapi.InlineKeyboardMarkup) {
+	chunks := splitMessage(text, maxMessageLength)
+	for i, chunk := range chunks {
+		msg := tgbotapi.NewMessage(chatID, chunk)
+		if keyboard != nil && i == len(chunks)-1 {
+			msg.ReplyMarkup = keyboard
+		}
+		if _, err := bot.Send(msg); err != nil {
+			log.Printf("Ошибка отправки сообщения: %v", err)
+			metrics.sendFailed(sendMessage)
+		}
+	}
+}
+
+// splitMessage режет текст на куски не длиннее limit, стараясь резать
+// между задачами, потом между строками и только в крайнем случае посреди строки
+func splitMessage(text string, limit int) []string {
+	var chunks []string
+	for messageLength(text) > limit {
+		cut := cutIndex(text, limit)
+		chunks = append(chunks, strings.TrimRight(text[:cut], "\n"))
+		text = strings.TrimLeft(text[cut:], "\n")
+	}
+	return append(chunks, text)
+}
+
+// cutIndex - байтовая позиция, по которой нужно разрезать text
+func cutIndex(text string, limit int) int {
+	// самый длинный префикс, который помещается в лимит
+	fit, length := 0, 0
+	for i, r := range text {
+		length += utf16Len(r)
+		if length > limit {
+			break
+		}
+		fit = i + len(string(r))
+	}
+
+	for _, sep := range []string{"\n\n", "\n"} {
+		if i := strings.LastIndex(text[:fit], sep); i > 0 {
+			return i + len(sep)
+		}
+	}
+	return fit
+}
+
+func messageLength(text string) int {
+	length := 0
+	for _, r := range text {
+		length += utf16Len(r)
+	}
+	return length
+}
+
+// utf16Len - сколько единиц UTF-16 занимает символ, символы вне BMP (эмодзи) занимают две
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// callbackData - данные кнопки под списком. Телеграм принимает не больше 64 байт,
+// поэтому вместо тега в них пишется его короткий ключ: tasks#backend -> tasks~1a2b3c4d
+func callbackData(command, view string, page int, userID int64) string {
+	if base, tag := splitView(view); tag != "" {
+		view = base + "~" + tagKey(tag)
+	}
+	return fmt.Sprintf("%s:%s:%d:%d", command, view, page, userID)
+}
+
+// tagKey - ключ тега фиксированной длины для данных кнопок
+func tagKey(tag string) string {
+	h := fnv.New32a()
+	h.Write([]byte(tag))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPagination(t *testing.T) {
+	tds, bot := newTestBot(t)
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.pageSize = 2
+
+	for i := 1; i <= 5; i++ {
+		manager.addTasks(personalBoardID, Ivanov, "ivanov", fmt.Sprintf("/new задача %d", i))
+	}
+
+	handleUpdate(bot, manager, messageUpdate(Petrov, "/tasks 2"))
+
+	tds.Lock()
+	want := `3. задача 3 by @ivanov
+/assign_3
+
+4. задача 4 by @ivanov
+/assign_4
+
+страница 2 из 3, следующая: /tasks 3`
+	if have := tds.Answers[Petrov]; have != want {
+		t.Fatalf("bad /tasks 2:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+	wantKeyboard := [][]string{{"assign_3:tasks:2:512"}, {"assign_4:tasks:2:512"}, {"page:tasks:1:512", "page:tasks:3:512"}}
+	if have := keyboardData(t, tds.Keyboards[Petrov]); !reflect.DeepEqual(have, wantKeyboard) {
+		t.Fatalf("bad keyboard:\n\tWant: %v\n\tHave: %v", wantKeyboard, have)
+	}
+	tds.Unlock()
+
+	// листаем кнопкой на последнюю страницу
+	handleUpdate(bot, manager, callbackUpdate(Petrov, "q1", "page:tasks:3:512"))
+
+	tds.Lock()
+	want = `5. задача 5 by @ivanov
+/assign_5
+
+страница 3 из 3`
+	if have := tds.Edits[Petrov]; have != want {
+		t.Fatalf("bad page 3:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+	wantKeyboard = [][]string{{"assign_5:tasks:3:512"}, {"page:tasks:2:512"}}
+	if have := keyboardData(t, tds.Keyboards[Petrov]); !reflect.DeepEqual(have, wantKeyboard) {
+		t.Fatalf("bad keyboard:\n\tWant: %v\n\tHave: %v", wantKeyboard, have)
+	}
+	tds.Unlock()
+
+	// страница за пределами списка показывает последнюю
+	if have, _ := manager.showTasks(viewAll, 100, personalBoardID, Petrov); !strings.HasPrefix(have, "5. задача 5") {
+		t.Fatalf("bad out of range page: %s", have)
+	}
+	// на одной странице подписи и кнопок листания нет
+	manager.pageSize = 10
+	if have, keyboard := manager.showTasks(viewAll, 1, personalBoardID, Petrov); strings.Contains(have, "страница") ||
+		len(keyboard.InlineKeyboard) != 5 {
+		t.Fatalf("single page has paging: %s", have)
+	}
+}
+
+// TestMyTasksLayout - в /my задачи разделяются пустой строкой, как в /tasks и /owner
+func TestMyTasksLayout(t *testing.T) {
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new написать бота")
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new отчет")
+	manager.assignTasks(personalBoardID, "assign_1", Ivanov, "ivanov")
+	manager.assignTasks(personalBoardID, "assign_2", Ivanov, "ivanov")
+
+	want := `1. написать бота by @ivanov
+/unassign_1 /resolve_1
+
+2. отчет by @ivanov
+/unassign_2 /resolve_2`
+	if have := manager.getMyTasks(personalBoardID, Ivanov); have != want {
+		t.Fatalf("bad /my:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	rows := make([]string, 0, 100)
+	for i := 0; i < 100; i++ {
+		rows = append(rows, fmt.Sprintf("%d. %s", i, strings.Repeat("ы", 100)))
+	}
+	text := strings.Join(rows, "\n\n")
+
+	chunks := splitMessage(text, maxMessageLength)
+	if len(chunks) < 3 {
+		t.Fatalf("text of %d chars split into %d chunks", messageLength(text), len(chunks))
+	}
+	for _, chunk := range chunks {
+		if messageLength(chunk) > maxMessageLength {
+			t.Fatalf("chunk is too long: %d", messageLength(chunk))
+		}
+	}
+	// режем только между задачами
+	if have := strings.Join(chunks, "\n\n"); have != text {
+		t.Fatalf("text changed after split")
+	}
+
+	// одна огромная строка без переносов режется как есть, эмодзи занимают по два символа
+	long := strings.Repeat("🙂", 3000)
+	chunks = splitMessage(long, maxMessageLength)
+	if len(chunks) != 2 || messageLength(chunks[0]) != maxMessageLength || strings.Join(chunks, "") != long {
+		t.Fatalf("bad split of a single line: %d chunks", len(chunks))
+	}
+
+	if chunks := splitMessage("Нет задач", maxMessageLength); !reflect.DeepEqual(chunks, []string{"Нет задач"}) {
+		t.Fatalf("short text was split: %v", chunks)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	msgForbidden   = "forbidden"
+	msgNoAssignees = "no_assignees"
+
+	defaultResolveRule  = "assignee,owner,admin"
+	defaultUnassignRule = "assignee,admin"
+	// назначать задачу на других может только автор или администратор доски
+	delegateRule = "owner,admin"
+)
+
+// роли пользователя по отношению к задаче
+const (
+	roleAssignee = "assignee"
+	roleOwner    = "owner"
+	roleAdmin    = "admin"
+)
+
+var errUnknownRole = errors.New("unknown role")
+
+// accessRule - какие роли допускаются к действию с задачей,
+// пустое правило допускает любого, кто может менять задачи доски
+type accessRule map[string]bool
+
+// parseAccessRule разбирает список ролей через запятую: assignee,owner,admin
+func parseAccessRule(value string) (accessRule, error) {
+	rule := make(accessRule)
+	for _, role := range strings.Split(value, ",") {
+		role = strings.TrimSpace(role)
+		switch role {
+		case "":
+		case roleAssignee, roleOwner, roleAdmin:
+			rule[role] = true
+		default:
+			return nil, fmt.Errorf("%w: %q", errUnknownRole, role)
+		}
+	}
+	return rule, nil
+}
+
+func mustParseAccessRule(value string) accessRule {
+	rule, err := parseAccessRule(value)
+	if err != nil {
+		panic(err)
+	}
+	return rule
+}
+
+// isBoardAdmin - администратор доски, на которой лежит задача
+func (tm *TaskManager) isBoardAdmin(boardID, userID int64) bool {
+	return tm.boardRole(boardID, userID) == BoardAdmin
+}
+
+// allowed проверяет, есть ли у пользователя хоть одна роль, допущенная правилом
+func (tm *TaskManager) allowed(rule accessRule, task *Task, userID int64) bool {
+	switch {
+	case len(rule) == 0:
+		return tm.writable(task.BoardID, userID)
+	case rule[roleAssignee] && task.isAssignee(userID):
+		return true
+	case rule[roleOwner] && task.Owner.ID == userID:
+		return true
+	case rule[roleAdmin] && tm.isBoardAdmin(task.BoardID, userID):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Sidorov - обычный участник доски без отношения к задаче
+const Sidorov int64 = 2048
+
+// newPermissionsBoard - доска группы, которую завел Александров (он ее администратор),
+// с задачей Иванова, назначенной на Петрова, и еще одним участником
+func newPermissionsBoard(t *testing.T) (*telegramPresenter, int64) {
+	t.Helper()
+
+	const backend int64 = -100500
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.touchBoard(backend, "Backend", Alexandrov)
+	manager.touchBoard(backend, "Backend", Ivanov)
+	manager.touchBoard(backend, "Backend", Petrov)
+	manager.touchBoard(backend, "Backend", Sidorov)
+	manager.touchUser(Alexandrov, "aalexandrov", "")
+
+	manager.addTasks(backend, Ivanov, "ivanov", "/new написать бота")
+	manager.assignTasks(backend, "assign_1", Petrov, "ppetrov")
+
+	return manager, backend
+}
+
+func TestResolvePermissions(t *testing.T) {
+	const stranger int64 = 42
+
+	cases := []struct {
+		name     string
+		rule     string
+		userID   int64
+		userName string
+		want     string
+	}{
+		{"assignee", defaultResolveRule, Petrov, "ppetrov", `Задача "написать бота" выполнена`},
+		{"owner", defaultResolveRule, Ivanov, "ivanov", `Задача "написать бота" выполнена`},
+		{"admin", defaultResolveRule, Alexandrov, "aalexandrov", `Задача "написать бота" выполнена`},
+		{"stranger", defaultResolveRule, stranger, "stranger", ru(msgForbidden)},
+		{"owner not allowed", "assignee", Ivanov, "ivanov", ru(msgForbidden)},
+		{"admin not allowed", "assignee,owner", Alexandrov, "aalexandrov", ru(msgForbidden)},
+		{"assignee not allowed", "admin", Petrov, "ppetrov", ru(msgForbidden)},
+		// пустое правило - любой участник доски, но не посторонний
+		{"empty rule member", "", Sidorov, "ssidorov", `Задача "написать бота" выполнена`},
+		{"empty rule stranger", "", stranger, "stranger", ru(msgForbidden)},
+	}
+
+	for _, item := range cases {
+		t.Run(item.name, func(t *testing.T) {
+			manager, backend := newPermissionsBoard(t)
+			manager.resolveRule = mustParseAccessRule(item.rule)
+
+			if have, _ := manager.resolveTasks(backend, "resolve_1", "", item.userID, item.userName); have != item.want {
+				t.Fatalf("bad resolve response:\n\tWant: %v\n\tHave: %v", item.want, have)
+			}
+		})
+	}
+
+	manager, backend := newPermissionsBoard(t)
+	if have, _ := manager.resolveTasks(backend, "resolve_2", "", Ivanov, "ivanov"); have != ru(msgLogNoTasks) {
+		t.Fatalf("bad resolve response for missing task: %v", have)
+	}
+}
+
+func TestUnassignPermissions(t *testing.T) {
+	cases := []struct {
+		name          string
+		rule          string
+		userID        int64
+		userName      string
+		want          string
+		notifications []notification
+	}{
+		{"assignee", defaultUnassignRule, Petrov, "ppetrov", ru(msgAccepted), []notification{
+			{Ivanov, `Задача "написать бота" осталась без исполнителя`},
+		}},
+		{"admin", defaultUnassignRule, Alexandrov, "aalexandrov", ru(msgAccepted), []notification{
+			{Petrov, `Задача "написать бота" снята с вас пользователем @aalexandrov`},
+			{Ivanov, `Задача "написать бота" осталась без исполнителя`},
+		}},
+		{"owner", defaultUnassignRule, Ivanov, "ivanov", ru(msgNotAssignee), nil},
+		{"owner allowed", "assignee,owner", Ivanov, "ivanov", ru(msgAccepted), []notification{
+			{Petrov, `Задача "написать бота" снята с вас пользователем @ivanov`},
+		}},
+		{"assignee not allowed", "admin", Petrov, "ppetrov", ru(msgForbidden), nil},
+		{"empty rule member", "", Sidorov, "ssidorov", ru(msgAccepted), []notification{
+			{Petrov, `Задача "написать бота" снята с вас пользователем @ssidorov`},
+			{Ivanov, `Задача "написать бота" осталась без исполнителя`},
+		}},
+		{"empty rule stranger", "", 42, "stranger", ru(msgNotAssignee), nil},
+	}
+
+	for _, item := range cases {
+		t.Run(item.name, func(t *testing.T) {
+			manager, backend := newPermissionsBoard(t)
+			manager.unassignRule = mustParseAccessRule(item.rule)
+
+			have, notifications := manager.unassignTasks(backend, "unassign_1", item.userID, item.userName)
+			if have != item.want || !reflect.DeepEqual(notifications, item.notifications) {
+				t.Fatalf("bad unassign response:\n\tWant: %v %v\n\tHave: %v %v", item.want, item.notifications, have, notifications)
+			}
+		})
+	}
+
+	manager, backend := newPermissionsBoard(t)
+	manager.unassignTasks(backend, "unassign_1", Petrov, "ppetrov")
+	if have, _ := manager.unassignTasks(backend, "unassign_1", Alexandrov, "aalexandrov"); have != ru(msgNoAssignees) {
+		t.Fatalf("bad unassign response for task without assignee: %v", have)
+	}
+	if have, _ := manager.unassignTasks(backend, "unassign_2", Alexandrov, "aalexandrov"); have != ru(msgLogNoTasks) {
+		t.Fatalf("bad unassign response for missing task: %v", have)
+	}
+}
+
+func TestParseAccessRule(t *testing.T) {
+	rule, err := parseAccessRule(" owner, admin ")
+	if err != nil || !reflect.DeepEqual(rule, accessRule{roleOwner: true, roleAdmin: true}) {
+		t.Fatalf("bad rule: %v %v", rule, err)
+	}
+	if _, err := parseAccessRule("assignee,moderator"); !errors.Is(err, errUnknownRole) {
+		t.Fatalf("unknown role accepted: %v", err)
+	}
+}
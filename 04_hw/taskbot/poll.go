@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/skinass/telegram-bot-api/v5"
+)
+
+// режимы получения апдейтов, -tg.mode
+const (
+	modeWebhook = "webhook"
+	modePoll    = "poll"
+)
+
+// startPolling забирает апдейты через getUpdates, начиная с сохраненного offset.
+// Пока у бота есть вебхук, телеграм не отдает апдейты через getUpdates, поэтому вебхук удаляется
+func startPolling(ctx context.Context, bot *tgbotapi.BotAPI, manager *TaskManager) (tgbotapi.UpdatesChannel, error) {
+	if _, err := bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		return nil, fmt.Errorf("DeleteWebhook failed: %w", err)
+	}
+
+	config := tgbotapi.NewUpdate(manager.updateOffset())
+	config.Timeout = int(PollTimeout.Seconds())
+
+	return trackOffset(ctx, bot.GetUpdatesChan(config), manager), nil
+}
+
+// offsetSaveEvery - как часто сохранять offset в хранилище. Каждая запись - строка
+// в журнале, поэтому offset пишется не на каждый апдейт, а пачками
+const offsetSaveEvery = 5 * time.Second
+
+// trackOffset запоминает offset полученных апдейтов. Телеграм считает апдейт
+// доставленным, как только следующий getUpdates уходит с большим offset,
+// поэтому offset запоминается при получении, а не после обработки.
+// В хранилище он попадает раз в offsetSaveEvery и при остановке, так что
+// после падения бот может повторно обработать апдейты только за этот промежуток.
+//
+// После отмены ctx канал закрывается сразу, не дожидаясь getUpdates, который уже
+// висит в long polling: tgbotapi проверяет остановку только между запросами.
+// Апдейты из этого запроса не подтверждены и придут снова после перезапуска
+func trackOffset(ctx context.Context, updates tgbotapi.UpdatesChannel, manager *TaskManager) tgbotapi.UpdatesChannel {
+	tracked := make(chan tgbotapi.Update, cap(updates))
+
+	go func() {
+		var offset, saved int
+		save := func() {
+			if offset > saved {
+				manager.saveUpdateOffset(offset)
+				saved = offset
+			}
+		}
+		// сначала сохраняем offset, потом закрываем канал: после этого хранилище закрывают
+		defer close(tracked)
+		defer save()
+
+		tick := manager.clock.After(offsetSaveEvery)
+		for {
+			select {
+			case update, ok := <-updates:
+				if !ok {
+					return
+				}
+				offset = update.UpdateID + 1
+				tracked <- update
+			case <-tick:
+				save()
+				tick = manager.clock.After(offsetSaveEvery)
+			case <-ctx.Done():
+				// уже полученные апдейты дообрабатываем, новых не ждем
+				for {
+					select {
+					case update, ok := <-updates:
+						if !ok {
+							return
+						}
+						offset = update.UpdateID + 1
+						tracked <- update
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return tracked
+}
+
+func (tm *TaskManager) updateOffset() int {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	return tm.storage.UpdateOffset()
+}
+
+func (tm *TaskManager) saveUpdateOffset(offset int) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if offset <= tm.storage.UpdateOffset() {
+		return
+	}
+	if err := tm.storage.SaveUpdateOffset(offset); err != nil {
+		log.Printf("Ошибка сохранения offset: %v", err)
+	}
+}
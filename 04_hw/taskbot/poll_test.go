@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/skinass/telegram-bot-api/v5"
+)
+
+func TestPollMode(t *testing.T) {
+	tds := NewTDS()
+	ts := httptest.NewServer(tds)
+	defer ts.Close()
+	tgbotapi.APIEndpoint = ts.URL + "/bot%s/%s"
+
+	mode, storagePath := BotMode, StoragePath
+	BotMode, StoragePath = modePoll, filepath.Join(t.TempDir(), "tasks.journal")
+	defer func() {
+		BotMode, StoragePath = mode, storagePath
+	}()
+
+	send := func(updateID int, userID int64, text string) {
+		update := messageUpdate(userID, text)
+		update.UpdateID = updateID
+		tds.Lock()
+		tds.Updates = append(tds.Updates, update)
+		tds.Unlock()
+	}
+
+	// run запускает бота, ждет ответов и останавливает его
+	run := func(want map[int64]string) {
+		t.Helper()
+
+		tds.Lock()
+		tds.Answers = make(map[int64]string)
+		tds.Unlock()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- startTaskBot(ctx)
+		}()
+
+		deadline := time.Now().Add(time.Second)
+		for {
+			tds.Lock()
+			have := tds.Answers
+			ok := reflect.DeepEqual(have, want)
+			tds.Unlock()
+			if ok {
+				break
+			}
+			if time.Now().After(deadline) {
+				cancel()
+				t.Fatalf("bad results:\n\tWant: %v\n\tHave: %v", want, have)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		cancel()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("startTaskBot error: %s", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("bot did not stop")
+		}
+	}
+
+	send(10, Ivanov, "/new написать бота")
+	run(map[int64]string{
+		Ivanov: `Задача "написать бота" создана, id=1`,
+	})
+
+	// после перезапуска бот продолжает с сохраненного offset и не создает задачу второй раз
+	send(11, Ivanov, "/tasks")
+	run(map[int64]string{
+		Ivanov: "1. написать бота by @ivanov\n/assign_1",
+	})
+
+	tds.Lock()
+	defer tds.Unlock()
+	if tds.Offsets[0] != 0 {
+		t.Fatalf("first poll must start from zero offset, have %d", tds.Offsets[0])
+	}
+	restarted := false
+	for _, offset := range tds.Offsets {
+		if offset == 11 {
+			restarted = true
+		}
+		if offset < 11 && restarted {
+			t.Fatalf("offset went back after restart: %v", tds.Offsets)
+		}
+	}
+	if !restarted {
+		t.Fatalf("offset 11 was never requested: %v", tds.Offsets)
+	}
+}
+
+func TestOffsetSavedInBatches(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.journal")
+	storage, err := OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("OpenFileStorage error: %s", err)
+	}
+	manager := NewTaskManager(storage)
+	clock := newFakeClock(time.Date(2026, 10, 17, 10, 0, 0, 0, time.Local))
+	manager.clock = clock
+
+	updates := make(chan tgbotapi.Update)
+	tracked := trackOffset(context.Background(), updates, manager)
+	feed := func(from, to int) {
+		for id := from; id < to; id++ {
+			updates <- tgbotapi.Update{UpdateID: id}
+			<-tracked
+		}
+	}
+
+	feed(0, 50)
+	clock.WaitWaiters(t)
+	clock.Advance(offsetSaveEvery)
+	clock.WaitWaiters(t)
+	feed(50, 100)
+	close(updates)
+	for range tracked {
+	}
+	storage.Close()
+
+	journal, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read journal error: %s", err)
+	}
+	if n := strings.Count(string(journal), `"op":"offset"`); n != 2 {
+		t.Fatalf("want 2 offset records for 100 updates, have %d", n)
+	}
+	if offset := manager.updateOffset(); offset != 100 {
+		t.Fatalf("offset %d is saved, want 100", offset)
+	}
+}
+
+func TestPollShutdownDuringLongPoll(t *testing.T) {
+	tds := NewTDS()
+	tds.PollDelay = time.Minute
+	ts := httptest.NewServer(tds)
+	defer ts.Close()
+	defer ts.CloseClientConnections()
+	tgbotapi.APIEndpoint = ts.URL + "/bot%s/%s"
+
+	mode, storagePath := BotMode, StoragePath
+	BotMode, StoragePath = modePoll, filepath.Join(t.TempDir(), "tasks.journal")
+	defer func() {
+		BotMode, StoragePath = mode, storagePath
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- startTaskBot(ctx)
+	}()
+
+	// ждем, пока getUpdates повиснет в long polling
+	deadline := time.Now().Add(time.Second)
+	for {
+		tds.Lock()
+		polling := len(tds.Offsets) > 0
+		tds.Unlock()
+		if polling {
+			break
+		}
+		if time.Now().After(deadline) {
+			cancel()
+			t.Fatalf("getUpdates was never called")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("startTaskBot error: %s", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("bot waits for the long poll to finish")
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	tgbotapi "github.com/skinass/telegram-bot-api/v5"
+)
+
+const (
+	msgOverdue = "overdue"
+	msgDueSoon = "due_soon"
+)
+
+// Sender - часть tgbotapi.BotAPI, через которую бот сам пишет пользователям
+type Sender interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+}
+
+// notification - сообщение, которое бот отправляет по своей инициативе
+type notification struct {
+	ChatID int64
+	Text   string
+}
+
+// deadline - момент, когда задача становится просроченной:
+// срок указывается днем, поэтому задачу можно сделать до конца этого дня
+func (t *Task) deadline() time.Time {
+	return t.Due.AddDate(0, 0, 1)
+}
+
+// notifyReceivers - кому напоминать о задаче: исполнителям, а если их нет - автору
+func (t *Task) notifyReceivers() []*User {
+	if len(t.Assignees) > 0 {
+		return t.Assignees
+	}
+	return []*User{t.Owner}
+}
+
+// CollectReminders находит задачи, срок которых скоро истекает или уже истек,
+// и помечает их, чтобы каждое напоминание приходило только один раз
+func (tm *TaskManager) CollectReminders(before time.Duration) []Event {
+	tm.mu.Lock()
+	now := tm.clock.Now()
+	var events []Event
+
+	for _, task := range tm.getOpenTasks() {
+		if task.Due == nil || task.OverdueNotified {
+			continue
+		}
+
+		deadline := task.deadline()
+		switch {
+		case !now.Before(deadline):
+			task.OverdueNotified = true
+		case !task.DueReminded && !now.Before(deadline.Add(-before)):
+			task.DueReminded = true
+		default:
+			continue
+		}
+
+		if err := tm.saveTask(task); err != nil {
+			continue
+		}
+		if task.OverdueNotified {
+			events = append(events, TaskOverdue{Task: *task})
+		} else {
+			events = append(events, TaskDueSoon{Task: *task})
+		}
+	}
+	tm.mu.Unlock()
+
+	for _, event := range events {
+		tm.publish(event)
+	}
+	return events
+}
+
+// runReminders раз в every проверяет сроки задач и рассылает напоминания,
+// пока не отменят ctx
+func runReminders(ctx context.Context, bot Sender, p *telegramPresenter, every, before time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.clock.After(every):
+		}
+
+		for _, n := range p.reminders(before) {
+			if _, err := bot.Send(tgbotapi.NewMessage(n.ChatID, n.Text)); err != nil {
+				log.Printf("Ошибка отправки напоминания: %v", err)
+				metrics.sendFailed(sendReminder)
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/skinass/telegram-bot-api/v5"
+)
+
+// newTestBot поднимает отдельный TDS и клиента бота к нему, без вебхука
+func newTestBot(t *testing.T) (*TDS, *tgbotapi.BotAPI) {
+	t.Helper()
+
+	tds := NewTDS()
+	ts := httptest.NewServer(tds)
+	t.Cleanup(ts.Close)
+
+	tgbotapi.APIEndpoint = ts.URL + "/bot%s/%s"
+	bot, err := tgbotapi.NewBotAPI(BotToken)
+	if err != nil {
+		t.Fatalf("NewBotAPI error: %s", err)
+	}
+
+	return tds, bot
+}
+
+// waitAnswers ждет, пока TDS не получит ровно такие сообщения, и очищает их
+func waitAnswers(t *testing.T, tds *TDS, want map[int64]string) {
+	t.Helper()
+
+	var have map[int64]string
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		tds.Lock()
+		have = make(map[int64]string, len(tds.Answers))
+		for chatID, text := range tds.Answers {
+			have[chatID] = text
+		}
+		tds.Unlock()
+
+		if mapsEqual(have, want) {
+			tds.Lock()
+			tds.Answers = make(map[int64]string)
+			tds.Unlock()
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("bad answers:\n\tWant: %v\n\tHave: %v", want, have)
+}
+
+func mapsEqual(a, b map[int64]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+func TestReminders(t *testing.T) {
+	tds, bot := newTestBot(t)
+
+	clock := newFakeClock(time.Date(2026, 10, 17, 10, 0, 0, 0, time.Local))
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.clock = clock
+
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new написать бота due:2026-10-18")
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new прийти на хакатон due:2026-10-19")
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new сделать ДЗ")
+	manager.assignTasks(personalBoardID, "assign_1", Petrov, "ppetrov")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runReminders(ctx, bot, manager, time.Hour, 24*time.Hour)
+
+	// после Advance ждем, пока планировщик разошлет напоминания и снова уснет
+	clock.WaitWaiters(t)
+	step := func(d time.Duration) {
+		clock.Advance(d)
+		clock.WaitWaiters(t)
+	}
+
+	// до конца срока первой задачи больше суток - тишина
+	step(time.Hour)
+	waitAnswers(t, tds, map[int64]string{})
+
+	// 18.10 00:00 - до конца срока сутки, напоминаем исполнителю
+	step(13 * time.Hour)
+	waitAnswers(t, tds, map[int64]string{
+		Petrov: `Срок задачи "написать бота" истекает 18.10.2026`,
+	})
+
+	// повторно не напоминаем
+	step(time.Hour)
+	waitAnswers(t, tds, map[int64]string{})
+
+	// 19.10 00:00 - первая задача просрочена, у второй нет исполнителя - пишем автору
+	step(23 * time.Hour)
+	waitAnswers(t, tds, map[int64]string{
+		Petrov: `Задача "написать бота" просрочена, срок был 18.10.2026`,
+		Ivanov: `Срок задачи "прийти на хакатон" истекает 19.10.2026`,
+	})
+
+	// выполненные задачи не напоминают о себе
+	manager.resolveTasks(personalBoardID, "resolve_2", "", Ivanov, "ivanov")
+	step(48 * time.Hour)
+	waitAnswers(t, tds, map[int64]string{})
+
+	// открытая заново просроченная задача снова напоминает о себе, теперь автору
+	manager.resolveTasks(personalBoardID, "resolve_1", "", Petrov, "ppetrov")
+	manager.reopenTasks(personalBoardID, "reopen_1", Ivanov, "ivanov")
+	step(time.Hour)
+	waitAnswers(t, tds, map[int64]string{
+		Ivanov: `Задача "написать бота" просрочена, срок был 18.10.2026`,
+	})
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// BoardRole - роль пользователя на доске
+type BoardRole string
+
+const (
+	BoardAdmin  BoardRole = "admin"
+	BoardMember BoardRole = "member"
+	BoardViewer BoardRole = "viewer"
+)
+
+const (
+	msgAdminUsage    = "admin_usage"
+	msgRoleUsage     = "role_usage"
+	msgReadOnly      = "read_only"
+	msgLastAdmin     = "last_admin"
+	msgRoleAdmin     = "role_admin"
+	msgRoleMember    = "role_member"
+	msgRoleViewer    = "role_viewer"
+	msgYourRole      = "your_role"
+	msgRoleSet       = "role_set"
+	msgRoleChanged   = "role_changed"
+	msgTaskDeleted   = "task_deleted"
+	msgTaskDeletedBy = "task_deleted_by"
+)
+
+var boardRoles = map[string]BoardRole{
+	"admin":  BoardAdmin,
+	"member": BoardMember,
+	"viewer": BoardViewer,
+}
+
+// title - название роли на языке пользователя
+func (r BoardRole) title(lang string) string {
+	switch r {
+	case BoardAdmin:
+		return tr(lang, msgRoleAdmin)
+	case BoardViewer:
+		return tr(lang, msgRoleViewer)
+	}
+	return tr(lang, msgRoleMember)
+}
+
+// isBootstrapAdmin - администратор из -tg.admin, он администратор на всех досках
+func (tm *TaskManager) isBootstrapAdmin(userID int64) bool {
+	if tm.bootstrapAdmin == "" {
+		return false
+	}
+	user, ok := tm.storage.User(userID)
+	return ok && strings.EqualFold(user.UserName, strings.TrimPrefix(tm.bootstrapAdmin, "@"))
+}
+
+// boardRole - роль пользователя на доске, по умолчанию все участники
+func (tm *TaskManager) boardRole(boardID, userID int64) BoardRole {
+	if tm.isBootstrapAdmin(userID) {
+		return BoardAdmin
+	}
+	if board, ok := tm.storage.Board(boardID); ok {
+		if role, ok := board.Roles[userID]; ok {
+			return role
+		}
+	}
+	return BoardMember
+}
+
+// isServerAdmin - то же, что isBootstrapAdmin, для вызова без tm.mu
+func (tm *TaskManager) isServerAdmin(userID int64) bool {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	return tm.isBootstrapAdmin(userID)
+}
+
+// canRead - видит ли пользователь задачи доски: ее участники и администратор из -tg.admin
+func (tm *TaskManager) canRead(boardID, userID int64) bool {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	return tm.isMember(boardID, userID) || tm.isBootstrapAdmin(userID)
+}
+
+// canWrite - может ли пользователь менять задачи на доске: читатели
+// и те, кто с доской не работал, не могут
+func (tm *TaskManager) canWrite(boardID, userID int64) bool {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	return tm.writable(boardID, userID)
+}
+
+// writable - то же, что canWrite, вызывается под tm.mu
+func (tm *TaskManager) writable(boardID, userID int64) bool {
+	if !tm.isMember(boardID, userID) && !tm.isBootstrapAdmin(userID) {
+		return false
+	}
+	return tm.boardRole(boardID, userID) != BoardViewer
+}
+
+// Role - роль пользователя на доске
+func (tm *TaskManager) Role(boardID, userID int64) BoardRole {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	return tm.boardRole(boardID, userID)
+}
+
+// SetRole меняет роль пользователя userName на доске, это может сделать только администратор
+func (tm *TaskManager) SetRole(boardID int64, actor *User, userName string, role BoardRole) (RoleChanged, error) {
+	return change(tm, func() (RoleChanged, error) {
+		if tm.boardRole(boardID, actor.ID) != BoardAdmin {
+			return RoleChanged{}, ErrForbidden
+		}
+
+		user, ok := tm.findUser(userName)
+		if !ok {
+			return RoleChanged{}, &UnknownUserError{UserName: strings.TrimPrefix(userName, "@")}
+		}
+
+		board, ok := tm.storage.Board(boardID)
+		if !ok {
+			board = &Board{ID: boardID, Members: make(map[int64]bool)}
+		}
+		if board.Roles[user.ID] == BoardAdmin && role != BoardAdmin && board.countAdmins() == 1 {
+			return RoleChanged{}, ErrLastAdmin
+		}
+
+		if board.Roles == nil {
+			board.Roles = make(map[int64]BoardRole)
+		}
+		board.Roles[user.ID] = role
+		if err := tm.storage.SaveBoard(board); err != nil {
+			log.Printf("Ошибка сохранения доски: %v", err)
+			return RoleChanged{}, fmt.Errorf("%w: %v", ErrStorage, err)
+		}
+
+		return RoleChanged{
+			BoardID:    board.ID,
+			BoardTitle: board.Title,
+			User:       &User{ID: user.ID, UserName: user.UserName},
+			Role:       role,
+			By:         actor,
+		}, nil
+	})
+}
+
+func (b *Board) countAdmins() int {
+	count := 0
+	for _, role := range b.Roles {
+		if role == BoardAdmin {
+			count++
+		}
+	}
+	return count
+}
+
+// DeleteTask удаляет задачу совсем, это может сделать только администратор доски
+func (tm *TaskManager) DeleteTask(boardID, taskID int64, actor *User) (TaskDeleted, error) {
+	return change(tm, func() (TaskDeleted, error) {
+		if tm.boardRole(boardID, actor.ID) != BoardAdmin {
+			return TaskDeleted{}, ErrForbidden
+		}
+
+		task, err := tm.task(boardID, taskID)
+		if err != nil {
+			return TaskDeleted{}, err
+		}
+
+		if err := tm.storage.Delete(task.ID); err != nil {
+			log.Printf("Ошибка удаления задачи: %v", err)
+			return TaskDeleted{}, fmt.Errorf("%w: %v", ErrStorage, err)
+		}
+
+		return TaskDeleted{Task: *task, By: actor}, nil
+	})
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRoles(t *testing.T) {
+	tds, bot := newTestBot(t)
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.bootstrapAdmin = "@IVANOV"
+
+	cases := []testCase{
+		{Petrov, "/start", map[int64]string{
+			Petrov: ru(msgGreeting),
+		}},
+		{Alexandrov, "/start", map[int64]string{
+			Alexandrov: ru(msgGreeting),
+		}},
+		{Ivanov, "/role", map[int64]string{
+			Ivanov: "Ваша роль на доске: администратор",
+		}},
+		{Petrov, "/role", map[int64]string{
+			Petrov: "Ваша роль на доске: участник",
+		}},
+		// менять роли может только администратор
+		{Petrov, "/role @aalexandrov viewer", map[int64]string{
+			Petrov: ru(msgForbidden),
+		}},
+		{Ivanov, "/role @aalexandrov reader", map[int64]string{
+			Ivanov: ru(msgRoleUsage),
+		}},
+		{Ivanov, "/role @aalexandrov viewer", map[int64]string{
+			Ivanov:     "Роль @aalexandrov на доске: читатель",
+			Alexandrov: `Ваша роль на доске "Личные сообщения": читатель`,
+		}},
+		{Petrov, "/new написать бота", map[int64]string{
+			Petrov: `Задача "написать бота" создана, id=1`,
+		}},
+		// читатель видит задачи, но не может их менять
+		{Alexandrov, "/tasks", map[int64]string{
+			Alexandrov: "1. написать бота by @ppetrov\n/assign_1",
+		}},
+		{Alexandrov, "/assign_1", map[int64]string{
+			Alexandrov: ru(msgReadOnly),
+		}},
+		{Alexandrov, "/new прийти на хакатон", map[int64]string{
+			Alexandrov: ru(msgReadOnly),
+		}},
+		{Alexandrov, "/watch_1", map[int64]string{
+			Alexandrov: `Вы следите за задачей "написать бота"`,
+		}},
+		{Petrov, "/delete_1", map[int64]string{
+			Petrov: ru(msgForbidden),
+		}},
+		{Ivanov, "/admin @ppetrov", map[int64]string{
+			Ivanov: "Роль @ppetrov на доске: администратор",
+			Petrov: `Ваша роль на доске "Личные сообщения": администратор`,
+		}},
+		{Petrov, "/role @ppetrov member", map[int64]string{
+			Petrov: ru(msgLastAdmin),
+		}},
+		{Petrov, "/delete_1", map[int64]string{
+			Petrov:     `Задача "написать бота" удалена`,
+			Alexandrov: `Задача "написать бота" удалена @ppetrov`,
+		}},
+		{Petrov, "/tasks", map[int64]string{
+			Petrov: ru(msgNoTasks),
+		}},
+		{Petrov, "/admin", map[int64]string{
+			Petrov: ru(msgAdminUsage),
+		}},
+	}
+
+	for idx, item := range cases {
+		tds.Lock()
+		tds.Answers = make(map[int64]string)
+		tds.Unlock()
+
+		handleUpdate(bot, manager, messageUpdate(item.user, item.command))
+
+		tds.Lock()
+		if !reflect.DeepEqual(tds.Answers, item.answers) {
+			t.Fatalf("[case%d, %d: %s] bad results:\n\tWant: %v\n\tHave: %v", idx, item.user, item.command, item.answers, tds.Answers)
+		}
+		tds.Unlock()
+	}
+}
+
+func TestGroupCreatorIsAdmin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.journal")
+
+	storage, err := OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("OpenFileStorage error: %s", err)
+	}
+	const backend int64 = -100500
+	manager := newTelegramPresenter(NewTaskManager(storage))
+	manager.touchBoard(backend, "Backend", Alexandrov)
+	manager.touchBoard(backend, "Backend", Ivanov)
+	storage.Close()
+
+	storage, err = OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("reopen error: %s", err)
+	}
+	defer storage.Close()
+	manager = newTelegramPresenter(NewTaskManager(storage))
+
+	if role := manager.boardRole(backend, Alexandrov); role != BoardAdmin {
+		t.Fatalf("group creator has role %s", role)
+	}
+	if role := manager.boardRole(backend, Ivanov); role != BoardMember {
+		t.Fatalf("group member has role %s", role)
+	}
+	if role := manager.boardRole(personalBoardID, Alexandrov); role != BoardMember {
+		t.Fatalf("personal board has admin %s", role)
+	}
+}
+
+func TestReassignNeedsOwnerOrAdmin(t *testing.T) {
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.reassignRule = mustParseAccessRule("owner,admin")
+
+	const backend int64 = -100500
+	manager.touchBoard(backend, "Backend", Alexandrov)
+	manager.touchBoard(backend, "Backend", Ivanov)
+	manager.touchBoard(backend, "Backend", Petrov)
+	manager.touchUser(Alexandrov, "aalexandrov", "")
+	manager.touchUser(Ivanov, "ivanov", "")
+	manager.touchUser(Petrov, "ppetrov", "")
+
+	manager.addTasks(backend, Ivanov, "ivanov", "/new написать бота")
+	manager.assignTasks(backend, "assign_1", Ivanov, "ivanov")
+
+	// участник не может ни отдать чужую задачу, ни забрать ее себе
+	if my, _ := manager.delegateTasks(backend, "assign_1", "@aalexandrov", Petrov, "ppetrov"); my != ru(msgForbidden) {
+		t.Fatalf("member delegated a task: %s", my)
+	}
+	if my, _ := manager.assignTasks(backend, "assign_1", Petrov, "ppetrov"); my != ru(msgForbidden) {
+		t.Fatalf("member took over a task: %s", my)
+	}
+
+	// администратор переназначает любую задачу, автор забирает свою обратно
+	if my, _ := manager.delegateTasks(backend, "assign_1", "@ppetrov", Alexandrov, "aalexandrov"); my != `Задача "написать бота" назначена на @ppetrov` {
+		t.Fatalf("admin can not reassign: %s", my)
+	}
+	if my, _ := manager.assignTasks(backend, "assign_1", Ivanov, "ivanov"); my != `Задача "написать бота" назначена на вас` {
+		t.Fatalf("owner can not take the task back: %s", my)
+	}
+}
+
+// TestEmptyRulesByRole - пустые -tg.reassign и -tg.unassign пускают администраторов
+// и участников доски, но не читателей
+func TestEmptyRulesByRole(t *testing.T) {
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.reassignRule = mustParseAccessRule("")
+	manager.unassignRule = mustParseAccessRule("")
+
+	const backend int64 = -100500
+	manager.touchBoard(backend, "Backend", Alexandrov)
+	manager.touchBoard(backend, "Backend", Ivanov)
+	manager.touchBoard(backend, "Backend", Petrov)
+	manager.touchBoard(backend, "Backend", Sidorov)
+	manager.touchUser(Alexandrov, "aalexandrov", "")
+	manager.touchUser(Ivanov, "ivanov", "")
+	manager.touchUser(Petrov, "ppetrov", "")
+	manager.touchUser(Sidorov, "ssidorov", "")
+	manager.SetRole(backend, &User{ID: Alexandrov, UserName: "aalexandrov"}, "@ssidorov", BoardViewer)
+
+	manager.addTasks(backend, Ivanov, "ivanov", "/new написать бота")
+	manager.assignTasks(backend, "assign_1", Ivanov, "ivanov")
+
+	// читатель не забирает задачу и не снимает исполнителей
+	if my, _ := manager.assignTasks(backend, "assign_1", Sidorov, "ssidorov"); my != ru(msgForbidden) {
+		t.Fatalf("viewer took over a task: %s", my)
+	}
+	if my, _ := manager.unassignTasks(backend, "unassign_1", Sidorov, "ssidorov"); my != ru(msgNotAssignee) {
+		t.Fatalf("viewer unassigned a task: %s", my)
+	}
+
+	// участник забирает задачу себе и снимает исполнителей
+	if my, _ := manager.assignTasks(backend, "assign_1", Petrov, "ppetrov"); my != `Задача "написать бота" назначена на вас` {
+		t.Fatalf("member can not take over a task: %s", my)
+	}
+	if my, _ := manager.unassignTasks(backend, "unassign_1", Petrov, "ppetrov"); my != ru(msgAccepted) {
+		t.Fatalf("member can not unassign: %s", my)
+	}
+
+	// администратор делает то же самое
+	manager.assignTasks(backend, "assign_1", Ivanov, "ivanov")
+	if my, _ := manager.assignTasks(backend, "assign_1", Alexandrov, "aalexandrov"); my != `Задача "написать бота" назначена на вас` {
+		t.Fatalf("admin can not take over a task: %s", my)
+	}
+	manager.assignTasks(backend, "assign_1", Ivanov, "ivanov")
+	if my, _ := manager.unassignTasks(backend, "unassign_1", Alexandrov, "aalexandrov"); my != ru(msgAccepted) {
+		t.Fatalf("admin can not unassign: %s", my)
+	}
+}
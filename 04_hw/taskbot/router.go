@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/skinass/telegram-bot-api/v5"
+)
+
+// commandContext - все, что нужно обработчику команды
+type commandContext struct {
+	BoardID  int64
+	UserID   int64
+	UserName string
+	// Command - команда без слэша вместе с id задачи: assign_1
+	Command string
+	// Args - все, что написано после команды
+	Args string
+	// Text - сообщение целиком
+	Text string
+	// Lang - язык, на котором отвечать пользователю
+	Lang string
+}
+
+// commandReply - ответ пользователю и уведомления остальным
+type commandReply struct {
+	Text          string
+	Keyboard      *tgbotapi.InlineKeyboardMarkup
+	Notifications []notification
+}
+
+func textReply(text string) commandReply {
+	return commandReply{Text: text}
+}
+
+func notifyReply(text string, notifications []notification) commandReply {
+	return commandReply{Text: text, Notifications: notifications}
+}
+
+type commandHandler func(p *telegramPresenter, c commandContext) commandReply
+
+// command - описание одной команды бота
+type command struct {
+	Name string
+	// WithID - команда пишется с id задачи: /assign_$ID
+	WithID bool
+	// Args и Help - id сообщений с описанием аргументов и самой команды для /help
+	Args string
+	Help string
+	// ReadOnly - команда ничего не меняет и доступна читателям доски
+	ReadOnly bool
+	Handle   commandHandler
+}
+
+// usage - как пишется команда: /assign_$ID [@username]
+func (c *command) usage(lang string) string {
+	usage := "/" + c.Name
+	if c.WithID {
+		usage += "_$ID"
+	}
+	if c.Args != "" {
+		usage += " " + tr(lang, c.Args)
+	}
+	return usage
+}
+
+// commandRouter - реестр команд, по нему разбираются сообщения и строится /help
+type commandRouter struct {
+	commands []*command
+	byName   map[string]*command
+}
+
+func newCommandRouter() *commandRouter {
+	return &commandRouter{
+		byName: make(map[string]*command),
+	}
+}
+
+func (r *commandRouter) register(cmd command) {
+	if _, ok := r.byName[cmd.Name]; ok {
+		panic(fmt.Sprintf("command %q registered twice", cmd.Name))
+	}
+	r.commands = append(r.commands, &cmd)
+	r.byName[cmd.Name] = &cmd
+}
+
+// lookup находит команду по тексту без слэша: tasks, assign_1.
+// Имя должно совпадать целиком, поэтому assignee не считается командой assign
+func (r *commandRouter) lookup(text string) (*command, bool) {
+	name, id, withID := strings.Cut(text, "_")
+	cmd, ok := r.byName[name]
+	if !ok || cmd.WithID != withID || (withID && id == "") {
+		return nil, false
+	}
+	return cmd, true
+}
+
+// help - список команд в порядке регистрации
+func (r *commandRouter) help(lang string) string {
+	lines := make([]string, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		if cmd.Help == "" {
+			continue
+		}
+		lines = append(lines, cmd.usage(lang)+" - "+tr(lang, cmd.Help))
+	}
+	return strings.Join(lines, "\n")
+}
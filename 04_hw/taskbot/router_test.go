@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommandLookup(t *testing.T) {
+	cases := []struct {
+		text string
+		name string
+	}{
+		{"tasks", viewAll},
+		{"assign_1", "assign"},
+		{"unassign_12", "unassign"},
+		{"watch_3", "watch"},
+		{"unwatch_3", "unwatch"},
+		// имя команды должно совпадать целиком
+		{"assignee", ""},
+		{"assignee_1", ""},
+		{"tasksss", ""},
+		// id задачи либо обязателен, либо его не должно быть
+		{"assign", ""},
+		{"assign_", ""},
+		{"tasks_1", ""},
+		{"", ""},
+	}
+
+	for _, item := range cases {
+		cmd, ok := botCommands.lookup(item.text)
+		if item.name == "" {
+			if ok {
+				t.Fatalf("%q matched command %q", item.text, cmd.Name)
+			}
+			continue
+		}
+		if !ok || cmd.Name != item.name {
+			t.Fatalf("%q: want command %q, have %v %v", item.text, item.name, cmd, ok)
+		}
+	}
+}
+
+func TestCommandHelp(t *testing.T) {
+	r := newCommandRouter()
+	r.register(command{Name: "start"})
+	r.register(command{Name: "tasks", Args: msgArgsList, Help: msgHelpTasks})
+	r.register(command{Name: "assign", WithID: true, Args: msgArgsAssign, Help: msgHelpAssign})
+
+	want := "/tasks [#тег] [страница] - посмотреть все задачи\n" +
+		"/assign_$ID [@username] - взять задачу себе или назначить ее на другого пользователя"
+	if have := r.help(langRu); have != want {
+		t.Fatalf("bad help:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+	if have := r.help(langEn); !strings.HasPrefix(have, "/tasks [#tag] [page] - show all tasks\n") {
+		t.Fatalf("bad english help:\n%v", have)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("duplicate command registered")
+		}
+	}()
+	r.register(command{Name: "tasks"})
+}
+
+func TestUnknownCommands(t *testing.T) {
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new написать бота")
+
+	for _, text := range []string{"assignee_1", "resolved_1", "hello"} {
+		reply := runCommand(manager, commandContext{UserID: Petrov, UserName: "ppetrov", Command: text})
+		if reply.Text != ru(msgUnknownCommand) {
+			t.Fatalf("%q: bad reply %q", text, reply.Text)
+		}
+	}
+
+	reply := runCommand(manager, commandContext{UserID: Petrov, UserName: "ppetrov", Command: "help"})
+	for _, cmd := range botCommands.commands {
+		if cmd.Help != "" && !strings.Contains(reply.Text, cmd.usage(langRu)) {
+			t.Fatalf("/help misses %s:\n%s", cmd.usage(langRu), reply.Text)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+)
+
+const (
+	msgFindUsage    = "find_usage"
+	msgNothingFound = "nothing_found"
+)
+
+// taskQuery - разобранный запрос /find
+type taskQuery struct {
+	words      []string
+	assignee   string
+	owner      string
+	unassigned bool
+}
+
+// parseTaskQuery разбирает запрос вида "бот assignee:@ppetrov owner:me unassigned".
+// Вместо логина можно написать me - тогда подставится логин того, кто ищет.
+func parseTaskQuery(text, userName string) (taskQuery, bool) {
+	var query taskQuery
+
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		if value, ok := strings.CutPrefix(word, "assignee:"); ok {
+			query.assignee = queryUserName(value, userName)
+			continue
+		}
+		if value, ok := strings.CutPrefix(word, "owner:"); ok {
+			query.owner = queryUserName(value, userName)
+			continue
+		}
+		if word == "unassigned" {
+			query.unassigned = true
+			continue
+		}
+		query.words = append(query.words, word)
+	}
+
+	empty := len(query.words) == 0 && query.assignee == "" && query.owner == "" && !query.unassigned
+	return query, !empty
+}
+
+func queryUserName(value, userName string) string {
+	if value == "me" {
+		return strings.ToLower(userName)
+	}
+	return strings.TrimPrefix(value, "@")
+}
+
+func (q taskQuery) match(task *Task) bool {
+	if q.unassigned && len(task.Assignees) > 0 {
+		return false
+	}
+	if q.assignee != "" && !hasUserName(task.Assignees, q.assignee) {
+		return false
+	}
+	if q.owner != "" && strings.ToLower(task.Owner.UserName) != q.owner {
+		return false
+	}
+
+	text := strings.ToLower(task.Title + "\n" + task.Description + "\n" + formatTags(task.Tags))
+	for _, word := range q.words {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func hasUserName(users []*User, userName string) bool {
+	for _, user := range users {
+		if strings.ToLower(user.UserName) == userName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestFindTasks(t *testing.T) {
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new Написать бота\nна Go, с вебхуками")
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new прийти на хакатон")
+	manager.addTasks(personalBoardID, Petrov, "ppetrov", "/new сделать ДЗ про бота")
+	manager.addTasks(-1001, Petrov, "ppetrov", "/new бот для другой группы")
+	manager.assignTasks(personalBoardID, "assign_3", Petrov, "ppetrov")
+
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{"", ru(msgFindUsage)},
+		{"бота", `1. Написать бота by @ivanov
+на Go, с вебхуками
+/assign_1
+
+3. сделать ДЗ про бота by @ppetrov
+assignee: я
+/unassign_3 /resolve_3`},
+		// регистр не важен, ищем и в описании
+		{"ВЕБХУК", `1. Написать бота by @ivanov
+на Go, с вебхуками
+/assign_1`},
+		// все слова должны встретиться
+		{"бота хакатон", ru(msgNothingFound)},
+		{"unassigned", `1. Написать бота by @ivanov
+на Go, с вебхуками
+/assign_1
+
+2. прийти на хакатон by @ivanov
+/assign_2`},
+		{"assignee:me", `3. сделать ДЗ про бота by @ppetrov
+assignee: я
+/unassign_3 /resolve_3`},
+		{"owner:@IVANOV хакатон", `2. прийти на хакатон by @ivanov
+/assign_2`},
+		{"owner:me unassigned", ru(msgNothingFound)},
+		// задачи других досок не находятся
+		{"группы", ru(msgNothingFound)},
+	}
+
+	for _, item := range cases {
+		if have := manager.findTasks(personalBoardID, Petrov, "ppetrov", item.query); have != item.want {
+			t.Fatalf("[/find %s] bad result:\n\tWant: %v\n\tHave: %v", item.query, item.want, have)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/skinass/telegram-bot-api/v5"
+)
+
+// startTestBot запускает бота и ждет, пока поднимется HTTP-сервер.
+// Возвращает функцию, которая останавливает бота и ждет его завершения
+func startTestBot(t *testing.T) func() {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- startTaskBot(ctx)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		resp, err := client.Get(WebhookURL + "/state")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			cancel()
+			t.Fatalf("bot did not start: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return func() {
+		t.Helper()
+
+		// соединения клиента, по которым не было запросов, сервер при остановке ждет до 5 секунд
+		client.CloseIdleConnections()
+		cancel()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("startTaskBot error: %s", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("bot did not stop")
+		}
+	}
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	tds := NewTDS()
+	ts := httptest.NewServer(tds)
+	defer ts.Close()
+	tgbotapi.APIEndpoint = ts.URL + "/bot%s/%s"
+
+	storagePath := StoragePath
+	StoragePath = filepath.Join(t.TempDir(), "tasks.journal")
+	defer func() {
+		StoragePath = storagePath
+	}()
+
+	cases := []testCase{
+		{Ivanov, "/new написать бота", map[int64]string{
+			Ivanov: `Задача "написать бота" создана, id=1`,
+		}},
+		// задача пережила перезапуск
+		{Ivanov, "/tasks", map[int64]string{
+			Ivanov: "1. написать бота by @ivanov\n/assign_1",
+		}},
+	}
+
+	for idx, item := range cases {
+		tds.Lock()
+		tds.Answers = make(map[int64]string)
+		tds.Unlock()
+
+		stop := startTestBot(t)
+		if err := SendMsgToBot(item.user, item.command); err != nil {
+			t.Fatalf("[case%d] SendMsgToBot error: %s", idx, err)
+		}
+		// остановка сразу после отправки: принятый апдейт все равно должен быть обработан
+		stop()
+
+		tds.Lock()
+		if have := tds.Answers[item.user]; have != item.answers[item.user] {
+			t.Fatalf("[case%d] bad answer:\n\tWant: %v\n\tHave: %v", idx, item.answers[item.user], have)
+		}
+		tds.Unlock()
+	}
+
+	if resp, err := client.Get(WebhookURL + "/state"); err == nil {
+		resp.Body.Close()
+		t.Fatalf("HTTP server still works after shutdown")
+	}
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// TaskStorage - хранилище задач, с которым работает TaskManager.
+// Реализации не обязаны быть потокобезопасными, доступ сериализует TaskManager.
+// Задачи, доски и пользователи отдаются копиями: изменения попадают в хранилище
+// только через Save*, и если запись не удалась, в памяти остается прежнее
+type TaskStorage interface {
+	// NextID выдает следующий id задачи, id никогда не переиспользуются
+	NextID() (int64, error)
+	Get(id int64) (*Task, bool)
+	List() []*Task
+	Save(task *Task) error
+	Delete(id int64) error
+
+	Board(id int64) (*Board, bool)
+	Boards() []*Board
+	SaveBoard(board *Board) error
+
+	User(id int64) (*User, bool)
+	Users() []*User
+	SaveUser(user *User) error
+
+	// UpdateOffset - с какого апдейта продолжать getUpdates в режиме poll
+	UpdateOffset() int
+	SaveUpdateOffset(offset int) error
+
+	// AppendAudit дописывает запись в журнал изменений задач,
+	// записи журнала никогда не меняются и не удаляются
+	AppendAudit(entry *AuditEntry) error
+	// Audit - весь журнал изменений по порядку
+	Audit() []*AuditEntry
+
+	// Ping проверяет, что хранилище доступно, для /readyz
+	Ping() error
+	Close() error
+}
+
+type MemoryStorage struct {
+	tasks  map[int64]*Task
+	boards map[int64]*Board
+	users  map[int64]*User
+	audit  []*AuditEntry
+	lastID int64
+	offset int
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		tasks:  make(map[int64]*Task),
+		boards: make(map[int64]*Board),
+		users:  make(map[int64]*User),
+	}
+}
+
+func (s *MemoryStorage) NextID() (int64, error) {
+	s.lastID++
+	return s.lastID, nil
+}
+
+func (s *MemoryStorage) Get(id int64) (*Task, bool) {
+	task, ok := s.tasks[id]
+	if !ok {
+		return nil, false
+	}
+	return cloneTask(task), true
+}
+
+func (s *MemoryStorage) List() []*Task {
+	tasks := make([]*Task, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		tasks = append(tasks, cloneTask(task))
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].ID < tasks[j].ID
+	})
+
+	return tasks
+}
+
+func (s *MemoryStorage) Save(task *Task) error {
+	s.tasks[task.ID] = cloneTask(task)
+	if task.ID > s.lastID {
+		s.lastID = task.ID
+	}
+	return nil
+}
+
+func (s *MemoryStorage) Delete(id int64) error {
+	delete(s.tasks, id)
+	return nil
+}
+
+func (s *MemoryStorage) Board(id int64) (*Board, bool) {
+	board, ok := s.boards[id]
+	if !ok {
+		return nil, false
+	}
+	return cloneBoard(board), true
+}
+
+func (s *MemoryStorage) Boards() []*Board {
+	boards := make([]*Board, 0, len(s.boards))
+	for _, board := range s.boards {
+		boards = append(boards, cloneBoard(board))
+	}
+	return boards
+}
+
+func (s *MemoryStorage) SaveBoard(board *Board) error {
+	s.boards[board.ID] = cloneBoard(board)
+	return nil
+}
+
+func (s *MemoryStorage) User(id int64) (*User, bool) {
+	user, ok := s.users[id]
+	if !ok {
+		return nil, false
+	}
+	return cloneUser(user), true
+}
+
+func (s *MemoryStorage) Users() []*User {
+	users := make([]*User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, cloneUser(user))
+	}
+	return users
+}
+
+func (s *MemoryStorage) SaveUser(user *User) error {
+	s.users[user.ID] = cloneUser(user)
+	return nil
+}
+
+func (s *MemoryStorage) UpdateOffset() int {
+	return s.offset
+}
+
+func (s *MemoryStorage) SaveUpdateOffset(offset int) error {
+	s.offset = offset
+	return nil
+}
+
+func (s *MemoryStorage) AppendAudit(entry *AuditEntry) error {
+	s.audit = append(s.audit, entry)
+	return nil
+}
+
+func (s *MemoryStorage) Audit() []*AuditEntry {
+	return s.audit
+}
+
+func (s *MemoryStorage) Ping() error {
+	return nil
+}
+
+func (s *MemoryStorage) Close() error {
+	return nil
+}
+
+// cloneTask - глубокая копия задачи, ее можно менять, не трогая хранилище
+func cloneTask(task *Task) *Task {
+	c := *task
+	c.Due = cloneTime(task.Due)
+	c.Tags = append([]string(nil), task.Tags...)
+	c.Assignees = cloneUsers(task.Assignees)
+	c.Watchers = cloneUsers(task.Watchers)
+	c.LegacyAssignee = cloneUser(task.LegacyAssignee)
+	c.Owner = cloneUser(task.Owner)
+	if task.Resolutions != nil {
+		c.Resolutions = make([]Resolution, len(task.Resolutions))
+		for i, resolution := range task.Resolutions {
+			c.Resolutions[i] = cloneResolution(resolution)
+		}
+	}
+	if task.LegacyResolution != nil {
+		resolution := cloneResolution(*task.LegacyResolution)
+		c.LegacyResolution = &resolution
+	}
+	if task.Comments != nil {
+		c.Comments = make([]Comment, len(task.Comments))
+		for i, comment := range task.Comments {
+			comment.Author = cloneUser(comment.Author)
+			c.Comments[i] = comment
+		}
+	}
+	return &c
+}
+
+func cloneResolution(resolution Resolution) Resolution {
+	resolution.By = cloneUser(resolution.By)
+	resolution.ReopenedBy = cloneUser(resolution.ReopenedBy)
+	resolution.ReopenedAt = cloneTime(resolution.ReopenedAt)
+	return resolution
+}
+
+func cloneBoard(board *Board) *Board {
+	c := *board
+	c.Members = cloneMap(board.Members)
+	c.Roles = cloneMap(board.Roles)
+	c.LegacyAdmins = cloneMap(board.LegacyAdmins)
+	return &c
+}
+
+func cloneUser(user *User) *User {
+	if user == nil {
+		return nil
+	}
+	c := *user
+	return &c
+}
+
+func cloneUsers(users []*User) []*User {
+	if users == nil {
+		return nil
+	}
+	c := make([]*User, len(users))
+	for i, user := range users {
+		c[i] = cloneUser(user)
+	}
+	return c
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return nil
+	}
+	c := make(map[K]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+const (
+	journalOpSave   = "save"
+	journalOpDelete = "delete"
+	journalOpBoard  = "board"
+	journalOpUser   = "user"
+	journalOpOffset = "offset"
+	journalOpAudit  = "audit"
+)
+
+// journalRecord - одна строка журнала, пишется в формате JSON lines
+type journalRecord struct {
+	Op    string `json:"op"`
+	ID    int64  `json:"id"`
+	Task  *Task  `json:"task,omitempty"`
+	Board *Board `json:"board,omitempty"`
+	User  *User  `json:"user,omitempty"`
+	// Offset - для записей offset
+	Offset int         `json:"offset,omitempty"`
+	Audit  *AuditEntry `json:"audit,omitempty"`
+}
+
+// FileStorage хранит задачи в памяти и дописывает каждое изменение в журнал.
+// При открытии журнал проигрывается заново, поэтому задачи и счетчик id
+// переживают перезапуск бота.
+type FileStorage struct {
+	*MemoryStorage
+	file *os.File
+}
+
+func OpenFileStorage(path string) (*FileStorage, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open journal failed: %w", err)
+	}
+
+	s := &FileStorage{
+		MemoryStorage: NewMemoryStorage(),
+		file:          file,
+	}
+
+	if err := s.replay(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *FileStorage) replay() error {
+	reader := bufio.NewReader(s.file)
+	var offset int64
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				// запись оборвалась на середине (например, упал процесс) - отрезаем хвост
+				if err := s.file.Truncate(offset); err != nil {
+					return fmt.Errorf("truncate journal failed: %w", err)
+				}
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read journal failed: %w", err)
+		}
+
+		var rec journalRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("bad journal record at offset %d: %w", offset, err)
+		}
+		s.apply(rec)
+		offset += int64(len(line))
+	}
+
+	if _, err := s.file.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("seek journal failed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *FileStorage) apply(rec journalRecord) {
+	switch rec.Op {
+	case journalOpSave:
+		if rec.Task != nil {
+			if rec.Task.LegacyAssignee != nil {
+				rec.Task.Assignees = []*User{rec.Task.LegacyAssignee}
+				rec.Task.LegacyAssignee = nil
+			}
+			if rec.Task.LegacyResolution != nil {
+				rec.Task.Resolutions = []Resolution{*rec.Task.LegacyResolution}
+				rec.Task.LegacyResolution = nil
+			}
+			//nolint:errcheck
+			s.MemoryStorage.Save(rec.Task)
+		}
+	case journalOpDelete:
+		//nolint:errcheck
+		s.MemoryStorage.Delete(rec.ID)
+	case journalOpBoard:
+		if rec.Board != nil {
+			for userID := range rec.Board.LegacyAdmins {
+				if rec.Board.Roles == nil {
+					rec.Board.Roles = make(map[int64]BoardRole)
+				}
+				rec.Board.Roles[userID] = BoardAdmin
+			}
+			rec.Board.LegacyAdmins = nil
+			//nolint:errcheck
+			s.MemoryStorage.SaveBoard(rec.Board)
+		}
+	case journalOpUser:
+		if rec.User != nil {
+			//nolint:errcheck
+			s.MemoryStorage.SaveUser(rec.User)
+		}
+	case journalOpOffset:
+		//nolint:errcheck
+		s.MemoryStorage.SaveUpdateOffset(rec.Offset)
+	case journalOpAudit:
+		if rec.Audit != nil {
+			//nolint:errcheck
+			s.MemoryStorage.AppendAudit(rec.Audit)
+		}
+	}
+}
+
+func (s *FileStorage) write(rec journalRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal journal record failed: %w", err)
+	}
+	data = append(data, '\n')
+
+	if _, err := s.file.Write(data); err != nil {
+		return fmt.Errorf("write journal failed: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("sync journal failed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *FileStorage) Save(task *Task) error {
+	if err := s.write(journalRecord{Op: journalOpSave, ID: task.ID, Task: task}); err != nil {
+		return err
+	}
+	return s.MemoryStorage.Save(task)
+}
+
+func (s *FileStorage) Delete(id int64) error {
+	if err := s.write(journalRecord{Op: journalOpDelete, ID: id}); err != nil {
+		return err
+	}
+	return s.MemoryStorage.Delete(id)
+}
+
+func (s *FileStorage) SaveBoard(board *Board) error {
+	if err := s.write(journalRecord{Op: journalOpBoard, ID: board.ID, Board: board}); err != nil {
+		return err
+	}
+	return s.MemoryStorage.SaveBoard(board)
+}
+
+func (s *FileStorage) SaveUser(user *User) error {
+	if err := s.write(journalRecord{Op: journalOpUser, ID: user.ID, User: user}); err != nil {
+		return err
+	}
+	return s.MemoryStorage.SaveUser(user)
+}
+
+func (s *FileStorage) SaveUpdateOffset(offset int) error {
+	if err := s.write(journalRecord{Op: journalOpOffset, Offset: offset}); err != nil {
+		return err
+	}
+	return s.MemoryStorage.SaveUpdateOffset(offset)
+}
+
+func (s *FileStorage) AppendAudit(entry *AuditEntry) error {
+	if err := s.write(journalRecord{Op: journalOpAudit, ID: entry.TaskID, Audit: entry}); err != nil {
+		return err
+	}
+	return s.MemoryStorage.AppendAudit(entry)
+}
+
+func (s *FileStorage) Ping() error {
+	if _, err := s.file.Stat(); err != nil {
+		return fmt.Errorf("stat journal failed: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStorage) Close() error {
+	return s.file.Close()
+}
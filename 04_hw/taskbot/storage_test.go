@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStorageRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.journal")
+
+	storage, err := OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("OpenFileStorage error: %s", err)
+	}
+	manager := newTelegramPresenter(NewTaskManager(storage))
+
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new написать бота")
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new прийти на хакатон")
+	manager.addTasks(personalBoardID, Petrov, "ppetrov", "/new сделать ДЗ по курсу")
+	manager.assignTasks(personalBoardID, "assign_1", Petrov, "ppetrov")
+	// последняя задача выполнена, ее id все равно не должен переиспользоваться
+	manager.resolveTasks(personalBoardID, "resolve_3", "", Petrov, "ppetrov")
+
+	if err := storage.Close(); err != nil {
+		t.Fatalf("Close error: %s", err)
+	}
+
+	storage, err = OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("reopen error: %s", err)
+	}
+	defer storage.Close()
+	manager = newTelegramPresenter(NewTaskManager(storage))
+
+	want := `1. написать бота by @ivanov
+assignee: @ppetrov
+
+2. прийти на хакатон by @ivanov
+/assign_2`
+	if have := manager.getAllTasks(personalBoardID, Ivanov); have != want {
+		t.Fatalf("bad tasks after restart:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+
+	want = `Задача "новая" создана, id=4`
+	if have := manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new новая"); have != want {
+		t.Fatalf("bad id after restart:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+}
+
+func TestFileStorageTruncatedTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.journal")
+
+	storage, err := OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("OpenFileStorage error: %s", err)
+	}
+	newTelegramPresenter(NewTaskManager(storage)).addTasks(personalBoardID, Ivanov, "ivanov", "/new написать бота")
+	storage.Close()
+
+	// имитируем запись, оборванную на середине
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("open journal error: %s", err)
+	}
+	//nolint:errcheck
+	file.WriteString(`{"op":"save","id":2,"task":{"ID":2,"Ti`)
+	file.Close()
+
+	storage, err = OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("reopen error: %s", err)
+	}
+	defer storage.Close()
+
+	want := `Задача "вторая" создана, id=2`
+	if have := newTelegramPresenter(NewTaskManager(storage)).addTasks(personalBoardID, Ivanov, "ivanov", "/new вторая"); have != want {
+		t.Fatalf("bad id after truncated journal:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+}
+
+func TestLegacyResolutionMigrated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.journal")
+
+	// журнал, в котором у задачи было только одно закрытие
+	journal := `{"op":"save","id":1,"task":{"ID":1,"Title":"написать бота","Owner":{"ID":1,"UserName":"ivanov"},"Resolution":{"By":{"ID":2,"UserName":"ppetrov"},"At":"2026-10-17T12:00:00Z","Note":"готово"}}}` + "\n"
+	if err := os.WriteFile(path, []byte(journal), 0o644); err != nil {
+		t.Fatalf("write journal error: %s", err)
+	}
+
+	storage, err := OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("OpenFileStorage error: %s", err)
+	}
+	defer storage.Close()
+
+	task, _ := storage.Get(1)
+	if !task.isResolved() || task.resolution().Note != "готово" || task.LegacyResolution != nil {
+		t.Fatalf("legacy resolution not migrated: %+v", task)
+	}
+}
+
+// TestFailedWriteKeepsMemory - если журнал не записался, в памяти остается прежнее состояние
+func TestFailedWriteKeepsMemory(t *testing.T) {
+	storage, err := OpenFileStorage(filepath.Join(t.TempDir(), "tasks.journal"))
+	if err != nil {
+		t.Fatalf("OpenFileStorage error: %s", err)
+	}
+	manager := NewTaskManager(storage)
+	manager.touchUser(Ivanov, "ivanov", "")
+	manager.touchUser(Petrov, "ppetrov", "")
+	manager.touchBoard(-100, "backend", Ivanov)
+	manager.touchBoard(-100, "backend", Petrov)
+
+	ivanov := &User{ID: Ivanov, UserName: "ivanov"}
+	created, err := manager.CreateTask(-100, ivanov, newTaskFields{Title: "написать бота"})
+	if err != nil {
+		t.Fatalf("CreateTask error: %s", err)
+	}
+	storage.file.Close()
+
+	if _, err := manager.ResolveTask(-100, created.Task.ID, ivanov, ""); !errors.Is(err, ErrStorage) {
+		t.Fatalf("want ErrStorage, have %v", err)
+	}
+	if _, err := manager.AssignTask(-100, created.Task.ID, ivanov, ""); !errors.Is(err, ErrStorage) {
+		t.Fatalf("want ErrStorage, have %v", err)
+	}
+	task, err := manager.Task(-100, created.Task.ID)
+	if err != nil {
+		t.Fatalf("Task error: %s", err)
+	}
+	if task.isResolved() || len(task.Assignees) != 0 {
+		t.Fatalf("failed changes are kept in memory: %+v", task)
+	}
+
+	manager.SetRole(-100, ivanov, "ppetrov", BoardViewer)
+	if role := manager.Role(-100, Petrov); role != BoardMember {
+		t.Fatalf("failed role change is kept in memory: %v", role)
+	}
+	manager.touchUser(Ivanov, "ivanov_new", "")
+	if user, _ := manager.User(Ivanov); user.UserName != "ivanov" {
+		t.Fatalf("failed user change is kept in memory: %+v", user)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	msgTagUsage   = "tag_usage"
+	msgBadTag     = "bad_tag"
+	msgNoTagsLeft = "no_tags_left"
+	msgTaskTags   = "task_tags"
+)
+
+var tagRe = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+
+// parseTag приводит тег к каноничному виду: без # и в нижнем регистре
+func parseTag(word string) (string, bool) {
+	tag := strings.ToLower(strings.TrimPrefix(word, "#"))
+	return tag, tagRe.MatchString(tag)
+}
+
+// addTags добавляет теги к набору, сохраняя его отсортированным и без повторов
+func addTags(tags []string, add ...string) []string {
+	tags = append([]string(nil), tags...)
+	for _, tag := range add {
+		if !hasTag(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func removeTags(tags []string, remove ...string) []string {
+	var result []string
+	for _, tag := range tags {
+		if !hasTag(remove, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func formatTags(tags []string) string {
+	words := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		words = append(words, "#"+tag)
+	}
+	return strings.Join(words, " ")
+}
+
+// splitView отделяет от списка задач фильтр по тегу: tasks#backend -> tasks, backend
+func splitView(view string) (string, string) {
+	base, tag, _ := strings.Cut(view, "#")
+	return base, tag
+}
+
+// viewCommand - команда, которой можно открыть тот же список: /tasks #backend
+func viewCommand(view string) string {
+	base, tag := splitView(view)
+	if tag == "" {
+		return "/" + base
+	}
+	return fmt.Sprintf("/%s #%s", base, tag)
+}
+
+// TagTask добавляет задаче теги, слова могут быть с # и без
+func (tm *TaskManager) TagTask(boardID, taskID int64, actor *User, words []string) (TagsChanged, error) {
+	return tm.changeTags(boardID, taskID, actor, words, addTags)
+}
+
+func (tm *TaskManager) UntagTask(boardID, taskID int64, actor *User, words []string) (TagsChanged, error) {
+	return tm.changeTags(boardID, taskID, actor, words, removeTags)
+}
+
+func (tm *TaskManager) changeTags(boardID, taskID int64, actor *User, words []string, apply func([]string, ...string) []string) (TagsChanged, error) {
+	return change(tm, func() (TagsChanged, error) {
+		var tags []string
+		for _, word := range words {
+			tag, ok := parseTag(word)
+			if !ok {
+				return TagsChanged{}, ErrBadTag
+			}
+			tags = append(tags, tag)
+		}
+		if len(tags) == 0 {
+			return TagsChanged{}, ErrNoTags
+		}
+
+		task, err := tm.openTask(boardID, taskID)
+		if err != nil {
+			return TagsChanged{}, err
+		}
+
+		task.Tags = apply(task.Tags, tags...)
+		if err := tm.saveTask(task); err != nil {
+			return TagsChanged{}, err
+		}
+
+		return TagsChanged{Task: *task, By: actor}, nil
+	})
+}
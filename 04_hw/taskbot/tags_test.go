@@ -0,0 +1,114 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	tds, bot := newTestBot(t)
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new поднять базу #Infra #backend")
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new написать API #backend")
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new обновить README")
+
+	cases := []struct {
+		command string
+		want    string
+	}{
+		{"/tasks #backend", `1. поднять базу by @ivanov
+теги: #backend #infra
+/assign_1
+
+2. написать API by @ivanov
+теги: #backend
+/assign_2`},
+		{"/tag_3 docs #Backend", `Теги задачи "обновить README": #backend #docs`},
+		{"/tag_3", ru(msgTagUsage)},
+		{"/tag_3 c++", ru(msgBadTag)},
+		{"/tag_42 docs", ru(msgLogNoTasks)},
+		{"/untag_1 backend", `Теги задачи "поднять базу": #infra`},
+		{"/untag_1 infra", `У задачи "поднять базу" больше нет тегов`},
+		{"/tasks #backend", `2. написать API by @ivanov
+теги: #backend
+/assign_2
+
+3. обновить README by @ivanov
+теги: #backend #docs
+/assign_3`},
+		{"/find #docs", `3. обновить README by @ivanov
+теги: #backend #docs
+/assign_3`},
+		{"/owner #nothing", ru(msgNoCreatedTasks)},
+	}
+
+	for _, item := range cases {
+		handleUpdate(bot, manager, messageUpdate(Ivanov, item.command))
+
+		tds.Lock()
+		have := tds.Answers[Ivanov]
+		tds.Unlock()
+		if have != item.want {
+			t.Fatalf("[%s] bad answer:\n\tWant: %v\n\tHave: %v", item.command, item.want, have)
+		}
+	}
+
+	// тег сохраняется в кнопках, чтобы после действия перерисовался тот же список
+	manager.pageSize = 1
+	handleUpdate(bot, manager, messageUpdate(Petrov, "/tasks #backend"))
+
+	tds.Lock()
+	defer tds.Unlock()
+	backend := "tasks~" + tagKey("backend")
+	want := [][]string{{"assign_2:" + backend + ":1:512"}, {"page:" + backend + ":2:512"}}
+	if have := keyboardData(t, tds.Keyboards[Petrov]); !reflect.DeepEqual(have, want) {
+		t.Fatalf("bad keyboard:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+	wantText := `2. написать API by @ivanov
+теги: #backend
+/assign_2
+
+страница 1 из 2, следующая: /tasks #backend 2`
+	if have := tds.Answers[Petrov]; have != wantText {
+		t.Fatalf("bad paged list:\n\tWant: %v\n\tHave: %v", wantText, have)
+	}
+}
+
+func TestLongTagCallbackData(t *testing.T) {
+	tds, bot := newTestBot(t)
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.pageSize = 1
+
+	// 40 кириллических букв - 80 байт, сам тег в callback_data уже не влезает
+	tag := strings.Repeat("инфраструктура", 3)[:80]
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new поднять базу #"+tag)
+	manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new поднять кэш #"+tag)
+	manager.assignTasks(personalBoardID, "assign_1", Ivanov, "ivanov")
+	handleUpdate(bot, manager, messageUpdate(Ivanov, "/tasks #"+tag))
+
+	tds.Lock()
+	rows := keyboardData(t, tds.Keyboards[Ivanov])
+	tds.Unlock()
+	if len(rows) != 2 {
+		t.Fatalf("bad keyboard: %v", rows)
+	}
+	for _, row := range rows {
+		for _, data := range row {
+			if len(data) > 64 {
+				t.Fatalf("callback_data is %d bytes: %s", len(data), data)
+			}
+		}
+	}
+
+	// по ключу тега перерисовывается тот же отфильтрованный список
+	handleUpdate(bot, manager, callbackUpdate(Ivanov, "q1", rows[1][0]))
+
+	tds.Lock()
+	defer tds.Unlock()
+	want := "2. поднять кэш by @ivanov\nтеги: #" + tag + "\n/assign_2\n\nстраница 2 из 2"
+	if have := tds.Edits[Ivanov]; have != want {
+		t.Fatalf("bad redrawn list:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+}
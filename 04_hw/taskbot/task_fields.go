@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dueLayout = "02.01.2006"
+
+type Priority int
+
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityNormal
+	PriorityHigh
+)
+
+var priorityNames = map[string]Priority{
+	"low":    PriorityLow,
+	"normal": PriorityNormal,
+	"high":   PriorityHigh,
+}
+
+const (
+	msgPriority   = "priority"
+	msgDue        = "due"
+	msgTags       = "tags"
+	msgPrioLow    = "priority_low"
+	msgPrioNormal = "priority_normal"
+	msgPrioHigh   = "priority_high"
+)
+
+// name - приоритет так, как его пишут в /new и в API: low, normal, high
+func (p Priority) name() string {
+	for name, priority := range priorityNames {
+		if priority == p {
+			return name
+		}
+	}
+	return ""
+}
+
+// title - название приоритета на языке пользователя
+func (p Priority) title(lang string) string {
+	switch p {
+	case PriorityLow:
+		return tr(lang, msgPrioLow)
+	case PriorityNormal:
+		return tr(lang, msgPrioNormal)
+	case PriorityHigh:
+		return tr(lang, msgPrioHigh)
+	}
+	return ""
+}
+
+var (
+	errEmptyTitle = errors.New("empty title")
+	errBadDue     = errors.New("bad due date")
+)
+
+// newTaskFields - то, что пользователь написал после /new
+type newTaskFields struct {
+	Title       string
+	Description string
+	Due         *time.Time
+	Priority    Priority
+	Tags        []string
+}
+
+// parseNewTask разбирает текст команды /new. Первая строка - название,
+// в ней же можно указать срок (due:2026-11-01 или due:+3d), приоритет (!high)
+// и теги (#backend). Все последующие строки идут в описание задачи.
+func parseNewTask(text string, now time.Time) (newTaskFields, error) {
+	var fields newTaskFields
+
+	header, description, _ := strings.Cut(text, "\n")
+	fields.Description = strings.TrimSpace(description)
+
+	var titleWords []string
+	for _, word := range strings.Fields(header) {
+		if value, ok := strings.CutPrefix(word, "due:"); ok {
+			due, err := parseDue(value, now)
+			if err != nil {
+				return fields, err
+			}
+			fields.Due = &due
+			continue
+		}
+		if strings.HasPrefix(word, "#") {
+			if tag, ok := parseTag(word); ok {
+				fields.Tags = addTags(fields.Tags, tag)
+				continue
+			}
+		}
+		if name, ok := strings.CutPrefix(word, "!"); ok {
+			if priority, known := priorityNames[strings.ToLower(name)]; known {
+				fields.Priority = priority
+				continue
+			}
+		}
+		titleWords = append(titleWords, word)
+	}
+
+	fields.Title = strings.Join(titleWords, " ")
+	if fields.Title == "" {
+		return fields, errEmptyTitle
+	}
+
+	return fields, nil
+}
+
+// parseDue понимает дату 2026-11-01 или смещение от сегодняшнего дня: +3d, +2w.
+// Срок всегда хранится как начало дня по локальному времени.
+func parseDue(value string, now time.Time) (time.Time, error) {
+	if offset, ok := strings.CutPrefix(value, "+"); ok {
+		if len(offset) < 2 {
+			return time.Time{}, fmt.Errorf("%w: %q", errBadDue, value)
+		}
+
+		count, err := strconv.Atoi(offset[:len(offset)-1])
+		if err != nil || count < 0 {
+			return time.Time{}, fmt.Errorf("%w: %q", errBadDue, value)
+		}
+
+		days := 0
+		switch offset[len(offset)-1] {
+		case 'd':
+			days = count
+		case 'w':
+			days = count * 7
+		default:
+			return time.Time{}, fmt.Errorf("%w: %q", errBadDue, value)
+		}
+
+		return startOfDay(now).AddDate(0, 0, days), nil
+	}
+
+	due, err := time.ParseInLocation("2006-01-02", value, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q", errBadDue, value)
+	}
+
+	return due, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// formatTaskDetails - строки с приоритетом, сроком и описанием,
+// которые выводятся под заголовком задачи
+func formatTaskDetails(task Task, lang string) string {
+	var details string
+	if task.Priority != PriorityNone {
+		details += tr(lang, msgPriority, task.Priority.title(lang))
+	}
+	if task.Due != nil {
+		details += tr(lang, msgDue, task.Due.Format(dueLayout))
+	}
+	if len(task.Tags) > 0 {
+		details += tr(lang, msgTags, formatTags(task.Tags))
+	}
+	if task.Description != "" {
+		details += "\n" + task.Description
+	}
+	return details
+}
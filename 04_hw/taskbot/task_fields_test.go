@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseNewTask(t *testing.T) {
+	now := time.Date(2026, 10, 17, 15, 30, 0, 0, time.Local)
+	date := func(year int, month time.Month, day int) *time.Time {
+		d := time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+		return &d
+	}
+
+	cases := []struct {
+		text string
+		want newTaskFields
+		err  error
+	}{
+		{
+			text: " написать бота",
+			want: newTaskFields{Title: "написать бота"},
+		},
+		{
+			text: " написать бота due:2026-11-01 !high",
+			want: newTaskFields{Title: "написать бота", Due: date(2026, 11, 1), Priority: PriorityHigh},
+		},
+		{
+			text: " !low due:+3d выкатить релиз\nсначала прогнать тесты\nпотом обновить changelog",
+			want: newTaskFields{
+				Title:       "выкатить релиз",
+				Description: "сначала прогнать тесты\nпотом обновить changelog",
+				Due:         date(2026, 10, 20),
+				Priority:    PriorityLow,
+			},
+		},
+		{
+			text: " ревью due:+2w",
+			want: newTaskFields{Title: "ревью", Due: date(2026, 10, 31)},
+		},
+		{
+			// неизвестный приоритет остается частью названия
+			text: " починить !всё",
+			want: newTaskFields{Title: "починить !всё"},
+		},
+		{
+			text: " !high due:+1d",
+			err:  errEmptyTitle,
+		},
+		{
+			text: " отчет due:завтра",
+			err:  errBadDue,
+		},
+		{
+			text: " отчет due:+3m",
+			err:  errBadDue,
+		},
+	}
+
+	for _, item := range cases {
+		have, err := parseNewTask(item.text, now)
+		if item.err != nil {
+			if !errors.Is(err, item.err) {
+				t.Fatalf("[%q] want error %v, have %v", item.text, item.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("[%q] unexpected error: %s", item.text, err)
+		}
+
+		if have.Title != item.want.Title || have.Description != item.want.Description ||
+			have.Priority != item.want.Priority ||
+			(have.Due == nil) != (item.want.Due == nil) ||
+			(have.Due != nil && !have.Due.Equal(*item.want.Due)) {
+			t.Fatalf("[%q] bad fields:\n\tWant: %+v\n\tHave: %+v", item.text, item.want, have)
+		}
+	}
+}
+
+func TestNewTaskDetails(t *testing.T) {
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+	manager.clock = newFakeClock(time.Date(2026, 10, 17, 12, 0, 0, 0, time.Local))
+
+	want := `Задача "выкатить релиз" создана, id=1`
+	if have := manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new выкатить релиз due:+3d !high\nпрогнать тесты"); have != want {
+		t.Fatalf("bad /new response:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+
+	want = `1. выкатить релиз by @ivanov
+приоритет: высокий
+срок: 20.10.2026
+прогнать тесты
+/assign_1`
+	if have := manager.getAllTasks(personalBoardID, Petrov); have != want {
+		t.Fatalf("bad /tasks:\n\tWant: %v\n\tHave: %v", want, have)
+	}
+
+	if have := manager.addTasks(personalBoardID, Ivanov, "ivanov", "/new отчет due:вчера"); have != ru(msgBadDue) {
+		t.Fatalf("bad response for wrong due: %v", have)
+	}
+}
@@ -36,12 +36,26 @@ var (
 type TDS struct {
 	*sync.Mutex
 	Answers map[int64]string
+	// последняя клавиатура (reply_markup) в сообщении для чата
+	Keyboards map[int64]string
+	// ответы на нажатия кнопок по id нажатия
+	Callbacks map[string]string
+	// последний отредактированный текст сообщения в чате
+	Edits map[int64]string
+	// апдейты, которые отдает getUpdates, и offset каждого запроса getUpdates
+	Updates []tgbotapi.Update
+	Offsets []int
+	// PollDelay - сколько getUpdates ждет новых апдейтов, как настоящий long polling
+	PollDelay time.Duration
 }
 
 func NewTDS() *TDS {
 	return &TDS{
-		Mutex:   &sync.Mutex{},
-		Answers: make(map[int64]string),
+		Mutex:     &sync.Mutex{},
+		Answers:   make(map[int64]string),
+		Keyboards: make(map[int64]string),
+		Callbacks: make(map[string]string),
+		Edits:     make(map[int64]string),
 	}
 }
 
@@ -57,12 +71,65 @@ func (srv *TDS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		//nolint:errcheck
 		w.Write([]byte(`{"ok":true,"result":true,"description":"Webhook was set"}`))
 	})
+	mux.HandleFunc("/deleteWebhook", func(w http.ResponseWriter, r *http.Request) {
+		//nolint:errcheck
+		w.Write([]byte(`{"ok":true,"result":true,"description":"Webhook was deleted"}`))
+	})
+	mux.HandleFunc("/getUpdates", func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.FormValue("offset"))
+		result := []tgbotapi.Update{}
+		srv.Lock()
+		srv.Offsets = append(srv.Offsets, offset)
+		for _, update := range srv.Updates {
+			if update.UpdateID >= offset {
+				result = append(result, update)
+			}
+		}
+		srv.Unlock()
+
+		if len(result) == 0 {
+			// вместо long polling
+			delay := 10 * time.Millisecond
+			srv.Lock()
+			if srv.PollDelay > 0 {
+				delay = srv.PollDelay
+			}
+			srv.Unlock()
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+			}
+		}
+		data, _ := json.Marshal(result)
+		//nolint:errcheck
+		w.Write([]byte(`{"ok":true,"result":` + string(data) + `}`))
+	})
 	mux.HandleFunc("/sendMessage", func(w http.ResponseWriter, r *http.Request) {
 		//nolint:errcheck
 		chatID, _ := strconv.ParseInt(r.FormValue("chat_id"), 10, 64)
 		text := r.FormValue("text")
 		srv.Lock()
 		srv.Answers[chatID] = text
+		srv.Keyboards[chatID] = r.FormValue("reply_markup")
+		srv.Unlock()
+
+		//nolint:errcheck
+		w.Write([]byte(`{"ok":true, "result":{"MessageID": 0}}`))
+	})
+	mux.HandleFunc("/answerCallbackQuery", func(w http.ResponseWriter, r *http.Request) {
+		srv.Lock()
+		srv.Callbacks[r.FormValue("callback_query_id")] = r.FormValue("text")
+		srv.Unlock()
+
+		//nolint:errcheck
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	})
+	mux.HandleFunc("/editMessageText", func(w http.ResponseWriter, r *http.Request) {
+		//nolint:errcheck
+		chatID, _ := strconv.ParseInt(r.FormValue("chat_id"), 10, 64)
+		srv.Lock()
+		srv.Edits[chatID] = r.FormValue("text")
+		srv.Keyboards[chatID] = r.FormValue("reply_markup")
 		srv.Unlock()
 
 		//nolint:errcheck
@@ -0,0 +1,646 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/skinass/telegram-bot-api/v5"
+)
+
+// telegramPresenter - телеграм-фронтенд над TaskManager: разбирает команды,
+// вызывает доменные методы и превращает их результаты, события и ошибки
+// в текст на языке пользователя и уведомления остальным
+type telegramPresenter struct {
+	*TaskManager
+}
+
+func newTelegramPresenter(tm *TaskManager) *telegramPresenter {
+	return &telegramPresenter{TaskManager: tm}
+}
+
+// errorMessages - какой текст показать для ошибки доменного API
+var errorMessages = []struct {
+	err error
+	id  string
+}{
+	{ErrTaskNotFound, msgLogNoTasks},
+	{ErrNotAssignee, msgNotAssignee},
+	{ErrForbidden, msgForbidden},
+	{ErrNoAssignees, msgNoAssignees},
+	{ErrNotResolved, msgNotResolved},
+	{ErrAlreadyAssignee, msgAlreadyAssignee},
+	{ErrAlreadyWatcher, msgAlreadyWatcher},
+	{ErrNotWatcher, msgNotWatcher},
+	{ErrEmptyComment, msgCommentUsage},
+	{ErrNoTags, msgTagUsage},
+	{ErrBadTag, msgBadTag},
+	{ErrLastAdmin, msgLastAdmin},
+	{ErrUnknownLanguage, msgLangUsage},
+	{ErrBadDigestTime, msgDigestUsage},
+	{errEmptyTitle, msgEmptyTitle},
+	{errBadDue, msgBadDue},
+}
+
+func errorText(lang string, err error) string {
+	var unknownUser *UnknownUserError
+	if errors.As(err, &unknownUser) {
+		return tr(lang, msgUnknownUser, unknownUser.UserName)
+	}
+	var notMember *NotMemberError
+	if errors.As(err, &notMember) {
+		return tr(lang, msgNotMember, notMember.UserName)
+	}
+	for _, item := range errorMessages {
+		if errors.Is(err, item.err) {
+			return tr(lang, item.id)
+		}
+	}
+	return tr(lang, msgStorageError)
+}
+
+// parseTaskID достает id задачи из команды вида assign_$ID.
+// Если id не разобрать, возвращает 0 - такой задачи не бывает,
+// и доменный метод сам ответит ErrTaskNotFound после проверки остальных аргументов
+func parseTaskID(command string) int64 {
+	_, value, ok := strings.Cut(command, "_")
+	if !ok {
+		log.Println("Некорректный формат команды — не найден ID")
+		return 0
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Printf("Ошибка конвертации string to int: %v", err)
+		return 0
+	}
+	return id
+}
+
+// fanOut собирает уведомления о событии: каждый получатель
+// получает не больше одного сообщения на своем языке, автор действия - ни одного
+type fanOut struct {
+	p             *telegramPresenter
+	actorID       int64
+	seen          map[int64]bool
+	notifications []notification
+}
+
+func (p *telegramPresenter) newFanOut(actorID int64) *fanOut {
+	return &fanOut{
+		p:       p,
+		actorID: actorID,
+		seen:    make(map[int64]bool),
+	}
+}
+
+func (f *fanOut) add(text phrase, users ...*User) {
+	for _, user := range users {
+		if user == nil || user.ID == f.actorID || f.seen[user.ID] {
+			continue
+		}
+		f.seen[user.ID] = true
+		f.notifications = append(f.notifications, notification{ChatID: user.ID, Text: text(f.p.languageOf(user.ID))})
+	}
+}
+
+// taskParticipants - все, кого касаются изменения задачи
+func (f *fanOut) addParticipants(text phrase, task Task) {
+	f.add(text, task.Owner)
+	f.add(text, task.Assignees...)
+	f.add(text, task.Watchers...)
+}
+
+func (p *telegramPresenter) getAllTasks(boardID, userID int64) string {
+	myResponse, _ := p.showTasks(viewAll, 1, boardID, userID)
+	return myResponse
+}
+
+func (p *telegramPresenter) getOwnTasks(boardID, userID int64) string {
+	myResponse, _ := p.showTasks(viewOwner, 1, boardID, userID)
+	return myResponse
+}
+
+func (p *telegramPresenter) getMyTasks(boardID, userID int64) string {
+	myResponse, _ := p.showTasks(viewMy, 1, boardID, userID)
+	return myResponse
+}
+
+// showTasks рендерит страницу списка задач для /tasks, /owner или /my
+// вместе с кнопками действий над ними и переключения страниц.
+// К списку можно добавить фильтр по тегу: tasks#backend
+func (p *telegramPresenter) showTasks(view string, page int, boardID, userID int64) (string, *tgbotapi.InlineKeyboardMarkup) {
+	base, tag := splitView(view)
+	lang := p.languageOf(userID)
+
+	var tasks []Task
+	for _, task := range p.OpenTasks(boardID) {
+		if tag != "" && !hasTag(task.Tags, tag) {
+			continue
+		}
+		switch base {
+		case viewOwner:
+			if task.Owner.ID != userID {
+				continue
+			}
+		case viewMy:
+			if !task.isAssignee(userID) {
+				continue
+			}
+		}
+		tasks = append(tasks, task)
+	}
+
+	if len(tasks) == 0 {
+		switch base {
+		case viewOwner:
+			return tr(lang, msgNoCreatedTasks), nil
+		case viewMy:
+			return tr(lang, msgNoYourTasks), nil
+		}
+		return tr(lang, msgNoTasks), nil
+	}
+
+	tasks, page, pages := paginate(tasks, page, p.pageSize)
+
+	rows := make([]string, 0, len(tasks))
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	for _, task := range tasks {
+		if base == viewMy {
+			// в /my нет метки assignee, исполнитель и так понятен
+			rows = append(rows, fmt.Sprintf("%d. %s by @%s%s\n/unassign_%d /resolve_%d",
+				task.ID, task.Title, task.Owner.UserName, formatTaskDetails(task, lang), task.ID, task.ID))
+		} else {
+			rows = append(rows, formatTaskResponse(task, userID, lang))
+		}
+
+		if row := taskButtons(task, userID, view, page, lang); len(row) > 0 {
+			buttons = append(buttons, row)
+		}
+	}
+
+	myResponse := strings.Join(rows, "\n\n")
+	if pages > 1 {
+		myResponse += tr(lang, msgPage, page, pages)
+		if page < pages {
+			myResponse += tr(lang, msgNextPage, viewCommand(view), page+1)
+		}
+		buttons = append(buttons, pageButtons(view, page, pages, userID, lang))
+	}
+
+	return myResponse, inlineKeyboard(buttons)
+}
+
+func (p *telegramPresenter) addTasks(boardID, userID int64, userName, commandText string) string {
+	lang := p.languageOf(userID)
+
+	fields, err := parseNewTask(commandArgs(commandText), p.clock.Now())
+	if err != nil {
+		return errorText(lang, err)
+	}
+
+	created, err := p.CreateTask(boardID, &User{ID: userID, UserName: userName}, fields)
+	if err != nil {
+		return errorText(lang, err)
+	}
+
+	return tr(lang, msgTaskCreated, created.Task.Title, created.Task.ID)
+}
+
+// assignTasks делает пользователя единственным исполнителем задачи.
+// Уведомление получают прежние исполнители, а если их не было - автор
+func (p *telegramPresenter) assignTasks(boardID int64, text string, userID int64, userName string) (string, []notification) {
+	return p.delegateTasks(boardID, text, "", userID, userName)
+}
+
+// delegateTasks назначает задачу на пользователя по логину: /assign_$ID @username.
+// Новый исполнитель получает уведомление лично, прежние исполнители, автор
+// и наблюдатели - как при обычном /assign_$ID.
+func (p *telegramPresenter) delegateTasks(boardID int64, text, mention string, userID int64, userName string) (string, []notification) {
+	lang := p.languageOf(userID)
+
+	assigned, err := p.AssignTask(boardID, parseTaskID(text), &User{ID: userID, UserName: userName}, mention)
+	if err != nil {
+		return errorText(lang, err), nil
+	}
+
+	task, assignee := assigned.Task, assigned.Task.Assignees[0]
+	if assignee.ID == userID {
+		return tr(lang, msgAssignedToYou, task.Title), p.notifications(assigned)
+	}
+	return tr(lang, msgAssignedTo, task.Title, assignee.UserName), p.notifications(assigned)
+}
+
+func (p *telegramPresenter) unassignTasks(boardID int64, text string, userID int64, userName string) (string, []notification) {
+	unassigned, err := p.UnassignTask(boardID, parseTaskID(text), &User{ID: userID, UserName: userName})
+	if err != nil {
+		return errorText(p.languageOf(userID), err), nil
+	}
+	return tr(p.languageOf(userID), msgAccepted), p.notifications(unassigned)
+}
+
+func (p *telegramPresenter) leaveTasks(boardID int64, text string, userID int64, userName string) (string, []notification) {
+	unassigned, err := p.LeaveTask(boardID, parseTaskID(text), &User{ID: userID, UserName: userName})
+	if err != nil {
+		return errorText(p.languageOf(userID), err), nil
+	}
+	return tr(p.languageOf(userID), msgAccepted), p.notifications(unassigned)
+}
+
+func (p *telegramPresenter) resolveTasks(boardID int64, text, note string, userID int64, userName string) (string, []notification) {
+	lang := p.languageOf(userID)
+
+	resolved, err := p.ResolveTask(boardID, parseTaskID(text), &User{ID: userID, UserName: userName}, note)
+	if err != nil {
+		return errorText(lang, err), nil
+	}
+
+	return tr(lang, msgResolved, resolved.Task.Title), p.notifications(resolved)
+}
+
+func (p *telegramPresenter) reopenTasks(boardID int64, text string, userID int64, userName string) (string, []notification) {
+	lang := p.languageOf(userID)
+
+	reopened, err := p.ReopenTask(boardID, parseTaskID(text), &User{ID: userID, UserName: userName})
+	if err != nil {
+		return errorText(lang, err), nil
+	}
+
+	return tr(lang, msgReopened, reopened.Task.Title), p.notifications(reopened)
+}
+
+func (p *telegramPresenter) getDoneTasks(boardID, userID int64) string {
+	lang := p.languageOf(userID)
+
+	done := p.DoneTasks(boardID, doneTasksLimit)
+	if len(done) == 0 {
+		return tr(lang, msgNoDoneTasks)
+	}
+
+	rows := make([]string, 0, len(done))
+	for _, task := range done {
+		rows = append(rows, formatDoneTaskResponse(task, lang))
+	}
+
+	return strings.Join(rows, "\n\n")
+}
+
+func (p *telegramPresenter) joinTasks(boardID int64, text string, userID int64, userName string) (string, []notification) {
+	lang := p.languageOf(userID)
+
+	joined, err := p.JoinTask(boardID, parseTaskID(text), &User{ID: userID, UserName: userName})
+	if err != nil {
+		return errorText(lang, err), nil
+	}
+
+	return tr(lang, msgJoined, joined.Task.Title), p.notifications(joined)
+}
+
+func (p *telegramPresenter) watchTasks(boardID int64, text string, userID int64, userName string) string {
+	lang := p.languageOf(userID)
+
+	watched, err := p.WatchTask(boardID, parseTaskID(text), &User{ID: userID, UserName: userName})
+	if err != nil {
+		return errorText(lang, err)
+	}
+
+	return tr(lang, msgWatching, watched.Task.Title)
+}
+
+func (p *telegramPresenter) unwatchTasks(boardID int64, text string, userID int64, userName string) string {
+	lang := p.languageOf(userID)
+
+	unwatched, err := p.UnwatchTask(boardID, parseTaskID(text), &User{ID: userID, UserName: userName})
+	if err != nil {
+		return errorText(lang, err)
+	}
+
+	return tr(lang, msgUnwatched, unwatched.Task.Title)
+}
+
+// commentTasks добавляет комментарий к задаче. Уведомление получают автор,
+// исполнители и наблюдатели задачи, кроме того, кто оставил комментарий.
+func (p *telegramPresenter) commentTasks(boardID int64, text, comment string, userID int64, userName string) (string, []notification) {
+	lang := p.languageOf(userID)
+
+	added, err := p.CommentTask(boardID, parseTaskID(text), &User{ID: userID, UserName: userName}, comment)
+	if err != nil {
+		return errorText(lang, err), nil
+	}
+
+	return tr(lang, msgCommentAdded, added.Task.Title), p.notifications(added)
+}
+
+// showTask показывает задачу целиком, вместе со всеми комментариями
+func (p *telegramPresenter) showTask(boardID int64, text string, userID int64) string {
+	lang := p.languageOf(userID)
+
+	task, err := p.Task(boardID, parseTaskID(text))
+	if err != nil {
+		return errorText(lang, err)
+	}
+
+	var myResponse string
+	if task.isResolved() {
+		myResponse = formatDoneTaskResponse(task, lang)
+	} else {
+		myResponse = formatTaskResponse(task, userID, lang)
+	}
+
+	if len(task.Comments) == 0 {
+		return myResponse + tr(lang, msgNoComments, task.ID)
+	}
+
+	myResponse += tr(lang, msgComments)
+	for _, comment := range task.Comments {
+		myResponse += fmt.Sprintf("\n@%s %s: %s", comment.Author.UserName, comment.At.Format(timeLayout), comment.Text)
+	}
+
+	return myResponse
+}
+
+// taskLog показывает историю изменений задачи: /log_$ID
+func (p *telegramPresenter) taskLog(boardID int64, text string, userID int64) string {
+	lang := p.languageOf(userID)
+
+	taskID := parseTaskID(text)
+	history, err := p.TaskHistory(boardID, taskID)
+	if err != nil {
+		return errorText(lang, err)
+	}
+	if len(history) == 0 {
+		return tr(lang, msgNoHistory, taskID)
+	}
+
+	rows := []string{tr(lang, msgAuditHeader, taskID)}
+	for _, entry := range history {
+		row := entry.At.Format(timeLayout)
+		if entry.Actor != nil {
+			row += " @" + entry.Actor.UserName
+		}
+		row += ": " + tr(lang, auditActions[entry.Action])
+		for _, change := range entry.Changes {
+			row += fmt.Sprintf("\n    %s: %s → %s", tr(lang, auditFields[change.Field]), auditText(change.Before), auditText(change.After))
+		}
+		rows = append(rows, row)
+	}
+
+	return strings.Join(rows, "\n")
+}
+
+// auditText - значение поля в истории, отсутствующее показывается прочерком
+func auditText(value string) string {
+	if value == "" {
+		return "—"
+	}
+	return value
+}
+
+func (p *telegramPresenter) tagTasks(boardID int64, text, args string, userID int64, userName string) string {
+	changed, err := p.TagTask(boardID, parseTaskID(text), &User{ID: userID, UserName: userName}, strings.Fields(args))
+	return p.taggedText(changed, err, userID)
+}
+
+func (p *telegramPresenter) untagTasks(boardID int64, text, args string, userID int64, userName string) string {
+	changed, err := p.UntagTask(boardID, parseTaskID(text), &User{ID: userID, UserName: userName}, strings.Fields(args))
+	return p.taggedText(changed, err, userID)
+}
+
+func (p *telegramPresenter) taggedText(changed TagsChanged, err error, userID int64) string {
+	lang := p.languageOf(userID)
+	if err != nil {
+		return errorText(lang, err)
+	}
+
+	if len(changed.Task.Tags) == 0 {
+		return tr(lang, msgNoTagsLeft, changed.Task.Title)
+	}
+	return tr(lang, msgTaskTags, changed.Task.Title, formatTags(changed.Task.Tags))
+}
+
+func (p *telegramPresenter) findTasks(boardID, userID int64, userName, text string) string {
+	lang := p.languageOf(userID)
+
+	query, ok := parseTaskQuery(text, userName)
+	if !ok {
+		return tr(lang, msgFindUsage)
+	}
+
+	var rows []string
+	for _, task := range p.OpenTasks(boardID) {
+		task := task
+		if query.match(&task) {
+			rows = append(rows, formatTaskResponse(task, userID, lang))
+		}
+	}
+	if len(rows) == 0 {
+		return tr(lang, msgNothingFound)
+	}
+
+	return strings.Join(rows, "\n\n")
+}
+
+func (p *telegramPresenter) getBoards(userID int64) string {
+	lang := p.languageOf(userID)
+
+	boards := p.Boards(userID)
+	if len(boards) == 0 {
+		return tr(lang, msgNoBoards)
+	}
+
+	rows := []string{tr(lang, msgBoards)}
+	for _, board := range boards {
+		rows = append(rows, tr(lang, msgBoardRow, boardTitle(board.ID, board.Title, lang), board.OpenTasks))
+	}
+
+	return strings.Join(rows, "\n")
+}
+
+// roleTasks обрабатывает /role: без аргументов показывает свою роль,
+// с аргументами меняет роль другого пользователя
+func (p *telegramPresenter) roleTasks(boardID int64, args string, userID int64, userName string) (string, []notification) {
+	lang := p.languageOf(userID)
+	fields := strings.Fields(args)
+	switch len(fields) {
+	case 0:
+		return tr(lang, msgYourRole, p.Role(boardID, userID).title(lang)), nil
+	case 2:
+		role, ok := boardRoles[strings.ToLower(fields[1])]
+		if !ok {
+			return tr(lang, msgRoleUsage), nil
+		}
+		return p.setRole(boardID, fields[0], role, userID, userName)
+	}
+	return tr(lang, msgRoleUsage), nil
+}
+
+// adminTasks обрабатывает /admin @username
+func (p *telegramPresenter) adminTasks(boardID int64, args string, userID int64, userName string) (string, []notification) {
+	fields := strings.Fields(args)
+	if len(fields) != 1 {
+		return tr(p.languageOf(userID), msgAdminUsage), nil
+	}
+	return p.setRole(boardID, fields[0], BoardAdmin, userID, userName)
+}
+
+func (p *telegramPresenter) setRole(boardID int64, mention string, role BoardRole, userID int64, userName string) (string, []notification) {
+	lang := p.languageOf(userID)
+
+	changed, err := p.SetRole(boardID, &User{ID: userID, UserName: userName}, mention, role)
+	if err != nil {
+		return errorText(lang, err), nil
+	}
+
+	return tr(lang, msgRoleSet, changed.User.UserName, role.title(lang)), p.notifications(changed)
+}
+
+func (p *telegramPresenter) deleteTasks(boardID int64, text string, userID int64, userName string) (string, []notification) {
+	lang := p.languageOf(userID)
+
+	// сначала права, чтобы не подсказывать, какие задачи существуют
+	if p.Role(boardID, userID) != BoardAdmin {
+		return errorText(lang, ErrForbidden), nil
+	}
+
+	deleted, err := p.DeleteTask(boardID, parseTaskID(text), &User{ID: userID, UserName: userName})
+	if err != nil {
+		return errorText(lang, err), nil
+	}
+
+	return tr(lang, msgTaskDeleted, deleted.Task.Title), p.notifications(deleted)
+}
+
+// setLang обрабатывает /lang: без аргументов показывает текущий язык
+func (p *telegramPresenter) setLang(args string, userID int64, userName string) string {
+	value := strings.TrimSpace(args)
+	if value == "" {
+		return tr(p.languageOf(userID), msgLangSet)
+	}
+
+	lang, ok := supportedLang(value)
+	if !ok {
+		return tr(p.languageOf(userID), msgLangUsage)
+	}
+	if err := p.SetLanguage(&User{ID: userID, UserName: userName}, lang); err != nil {
+		return errorText(p.languageOf(userID), err)
+	}
+
+	return tr(lang, msgLangSet)
+}
+
+// reminders - напоминания о сроках: исполнителям, а если их нет - автору
+func (p *telegramPresenter) reminders(before time.Duration) []notification {
+	var notifications []notification
+	for _, event := range p.CollectReminders(before) {
+		notifications = append(notifications, p.notifications(event)...)
+	}
+	return notifications
+}
+
+// notifications - кому и что написать о событии, кроме автора действия:
+// одинаково для команд в чате и для изменений через API
+func (p *telegramPresenter) notifications(event Event) []notification {
+	switch event := event.(type) {
+	case TaskAssigned:
+		return p.assignedNotifications(event)
+	case AssigneeJoined:
+		out := p.newFanOut(event.By.ID)
+		out.addParticipants(say(msgJoinedBy, event.By.UserName, event.Task.Title), event.Task)
+		return out.notifications
+	case TaskUnassigned:
+		return p.unassignedNotifications(event)
+	case TaskResolved:
+		task := event.Task
+		notice := func(lang string) string {
+			text := tr(lang, msgResolvedBy, task.Title, event.By.UserName)
+			if note := task.resolution().Note; note != "" {
+				text += tr(lang, msgResolutionNote, note)
+			}
+			return text
+		}
+		out := p.newFanOut(event.By.ID)
+		out.addParticipants(notice, task)
+		return out.notifications
+	case TaskReopened:
+		notice := say(msgReopenedBy, event.Task.Title, event.By.UserName)
+		out := p.newFanOut(event.By.ID)
+		out.add(notice, event.Task.Owner)
+		out.add(notice, event.Task.Watchers...)
+		return out.notifications
+	case CommentAdded:
+		out := p.newFanOut(event.Comment.Author.ID)
+		out.addParticipants(say(msgCommentBy, event.Comment.Author.UserName, event.Task.Title, event.Comment.Text), event.Task)
+		return out.notifications
+	case TaskDeleted:
+		out := p.newFanOut(event.By.ID)
+		out.addParticipants(say(msgTaskDeletedBy, event.Task.Title, event.By.UserName), event.Task)
+		return out.notifications
+	case RoleChanged:
+		if event.User.ID == event.By.ID {
+			return nil
+		}
+		lang := p.languageOf(event.User.ID)
+		return []notification{{
+			ChatID: event.User.ID,
+			Text:   tr(lang, msgRoleChanged, boardTitle(event.BoardID, event.BoardTitle, lang), event.Role.title(lang)),
+		}}
+	case TaskDueSoon:
+		return p.dueNotifications(event.Task, say(msgDueSoon, event.Task.Title, event.Task.Due.Format(dueLayout)))
+	case TaskOverdue:
+		return p.dueNotifications(event.Task, say(msgOverdue, event.Task.Title, event.Task.Due.Format(dueLayout)))
+	}
+	return nil
+}
+
+// assignedNotifications: новый исполнитель получает уведомление лично, если его назначил
+// кто-то другой, а еще предупреждаем прежних исполнителей (если их не было - автора) и наблюдателей
+func (p *telegramPresenter) assignedNotifications(assigned TaskAssigned) []notification {
+	task, assignee := assigned.Task, assigned.Task.Assignees[0]
+
+	out := p.newFanOut(assigned.By.ID)
+	out.add(say(msgAssignedToYouBy, task.Title, assigned.By.UserName), assignee)
+
+	// новый исполнитель уже знает о назначении
+	out.seen[assignee.ID] = true
+	notice := say(msgAssignedTo, task.Title, assignee.UserName)
+	if len(assigned.Previous) > 0 {
+		out.add(notice, assigned.Previous...)
+	} else {
+		out.add(notice, task.Owner)
+	}
+	out.add(notice, task.Watchers...)
+
+	return out.notifications
+}
+
+// unassignedNotifications: если пользователь отказался сам, остальные узнают, кто ушел,
+// а если администратор снял всех - снятые узнают, кто их снял
+func (p *telegramPresenter) unassignedNotifications(event TaskUnassigned) []notification {
+	task := event.Task
+	out := p.newFanOut(event.By.ID)
+	left := len(event.Removed) == 1 && event.Removed[0].ID == event.By.ID
+	if left && len(task.Assignees) > 0 {
+		out.addParticipants(say(msgAssigneeLeft, event.Removed[0].UserName, task.Title), task)
+		return out.notifications
+	}
+
+	if !left {
+		out.add(say(msgUnassignedBy, task.Title, event.By.UserName), event.Removed...)
+	}
+	notice := say(msgNoAssigneeLeft, task.Title)
+	out.add(notice, task.Owner)
+	out.add(notice, task.Watchers...)
+	return out.notifications
+}
+
+// dueNotifications - напоминание о сроке, автору действия тут нет
+func (p *telegramPresenter) dueNotifications(task Task, text phrase) []notification {
+	var notifications []notification
+	for _, user := range task.notifyReceivers() {
+		notifications = append(notifications, notification{ChatID: user.ID, Text: text(p.languageOf(user.ID))})
+	}
+	return notifications
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"log"
+	"strings"
+)
+
+const (
+	msgUnknownUser     = "unknown_user"
+	msgNotMember       = "not_member"
+	msgAssignedTo      = "assigned_to"
+	msgAssignedToYouBy = "assigned_to_you_by"
+)
+
+// touchUser запоминает пользователя, написавшего боту, чтобы потом
+// находить его по логину и писать ему на его языке.
+// В личном чате id чата совпадает с id пользователя.
+func (tm *TaskManager) touchUser(userID int64, userName, languageCode string) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	user := User{ID: userID}
+	if stored, ok := tm.storage.User(userID); ok {
+		if stored.UserName == userName && stored.LanguageCode == languageCode {
+			return
+		}
+		// выбранный язык и настройки сводки остаются прежними
+		user = *stored
+	}
+	user.UserName, user.LanguageCode = userName, languageCode
+
+	if err := tm.storage.SaveUser(&user); err != nil {
+		log.Printf("Ошибка сохранения пользователя: %v", err)
+	}
+}
+
+// User - пользователь по id, если он хоть раз писал боту
+func (tm *TaskManager) User(userID int64) (User, bool) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	user, ok := tm.storage.User(userID)
+	if !ok {
+		return User{}, false
+	}
+	return *user, true
+}
+
+// FindUser - пользователь по логину, если он хоть раз писал боту
+func (tm *TaskManager) FindUser(mention string) (User, bool) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	user, ok := tm.findUser(mention)
+	if !ok {
+		return User{}, false
+	}
+	return *user, true
+}
+
+// findUser ищет пользователя по логину, с @ или без
+func (tm *TaskManager) findUser(mention string) (*User, bool) {
+	userName := strings.TrimPrefix(strings.TrimSpace(mention), "@")
+	if userName == "" {
+		return nil, false
+	}
+
+	for _, user := range tm.storage.Users() {
+		if strings.EqualFold(user.UserName, userName) {
+			return user, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDelegate(t *testing.T) {
+	tds, bot := newTestBot(t)
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+
+	cases := []testCase{
+		{Ivanov, "/new написать бота", map[int64]string{
+			Ivanov: `Задача "написать бота" создана, id=1`,
+		}},
+		// Петров еще ни разу не писал боту
+		{Ivanov, "/assign_1 @ppetrov", map[int64]string{
+			Ivanov: "Пользователь @ppetrov не найден, он должен хотя бы раз написать боту",
+		}},
+		{Petrov, "/start", map[int64]string{
+			Petrov: ru(msgGreeting),
+		}},
+		// автор назначает задачу на Петрова
+		{Ivanov, "/assign_1 @PPetrov", map[int64]string{
+			Ivanov: `Задача "написать бота" назначена на @ppetrov`,
+			Petrov: `Задача "написать бота" назначена на вас пользователем @ivanov`,
+		}},
+		{Alexandrov, "/tasks", map[int64]string{
+			Alexandrov: "1. написать бота by @ivanov\nassignee: @ppetrov",
+		}},
+		// назначать чужую задачу на других может только автор или администратор
+		{Alexandrov, "/assign_1 ivanov", map[int64]string{
+			Alexandrov: ru(msgForbidden),
+		}},
+		// назначение на себя через упоминание работает как обычный /assign,
+		// прежний исполнитель узнает об этом
+		{Alexandrov, "/assign_1 @aalexandrov", map[int64]string{
+			Alexandrov: `Задача "написать бота" назначена на вас`,
+			Petrov:     `Задача "написать бота" назначена на @aalexandrov`,
+		}},
+		// автор передает задачу обратно Петрову
+		{Ivanov, "/assign_1 ppetrov", map[int64]string{
+			Ivanov:     `Задача "написать бота" назначена на @ppetrov`,
+			Petrov:     `Задача "написать бота" назначена на вас пользователем @ivanov`,
+			Alexandrov: `Задача "написать бота" назначена на @ppetrov`,
+		}},
+		{Alexandrov, "/assign_2 @ivanov", map[int64]string{
+			Alexandrov: ru(msgLogNoTasks),
+		}},
+	}
+
+	for idx, item := range cases {
+		tds.Lock()
+		tds.Answers = make(map[int64]string)
+		tds.Unlock()
+
+		handleUpdate(bot, manager, messageUpdate(item.user, item.command))
+
+		tds.Lock()
+		if !reflect.DeepEqual(tds.Answers, item.answers) {
+			t.Fatalf("[case%d, %d: %s] bad results:\n\tWant: %v\n\tHave: %v", idx, item.user, item.command, item.answers, tds.Answers)
+		}
+		tds.Unlock()
+	}
+}
+
+func TestDelegateToBoardMembers(t *testing.T) {
+	manager := newTelegramPresenter(NewTaskManager(NewMemoryStorage()))
+
+	const backend int64 = -100500
+	manager.touchBoard(backend, "backend", Ivanov)
+	manager.touchUser(Ivanov, "ivanov", "")
+	// Петров писал боту только в личку
+	manager.touchBoard(personalBoardID, personalBoardTitle, Petrov)
+	manager.touchUser(Petrov, "ppetrov", "")
+	manager.addTasks(backend, Ivanov, "ivanov", "/new написать бота")
+
+	my, notifications := manager.delegateTasks(backend, "assign_1", "@ppetrov", Ivanov, "ivanov")
+	if my != "Пользователь @ppetrov не работает с этой доской" || len(notifications) != 0 {
+		t.Fatalf("task is assigned to a non-member: %q %v", my, notifications)
+	}
+
+	manager.touchBoard(backend, "backend", Petrov)
+	if my, _ := manager.delegateTasks(backend, "assign_1", "@ppetrov", Ivanov, "ivanov"); my != `Задача "написать бота" назначена на @ppetrov` {
+		t.Fatalf("bad delegate to a member: %q", my)
+	}
+}
+
+func TestUsersPersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.journal")
+
+	storage, err := OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("OpenFileStorage error: %s", err)
+	}
+	manager := newTelegramPresenter(NewTaskManager(storage))
+	manager.touchUser(Petrov, "ppetrov", "")
+	manager.touchUser(Petrov, "petr", "")
+	storage.Close()
+
+	storage, err = OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("reopen error: %s", err)
+	}
+	defer storage.Close()
+	manager = newTelegramPresenter(NewTaskManager(storage))
+
+	if _, ok := manager.findUser("@ppetrov"); ok {
+		t.Fatalf("old username is still known")
+	}
+	if user, ok := manager.findUser("@petr"); !ok || user.ID != Petrov {
+		t.Fatalf("user was not restored: %+v", user)
+	}
+}